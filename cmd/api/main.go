@@ -10,19 +10,48 @@
 
 // @BasePath /subscriptions
 // @schemes http
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT of the user as "Bearer {token}"
 package main
 
 import (
+	"context"
+	"crypto/rsa"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/l-golofastov/subscriptions-manager/internal/config"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/apikeys"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/batch"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/breakdown"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/csvio"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/health"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/rates"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/subscriptions"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/sum"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/jwt"
+	"github.com/l-golofastov/subscriptions-manager/internal/metrics"
+	"github.com/l-golofastov/subscriptions-manager/internal/purge"
+	"github.com/l-golofastov/subscriptions-manager/internal/ratelimit"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository/memory"
 	"github.com/l-golofastov/subscriptions-manager/internal/repository/postgres"
+	"github.com/l-golofastov/subscriptions-manager/internal/tracing"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/l-golofastov/subscriptions-manager/docs"
@@ -35,25 +64,97 @@ func main() {
 
 	log.Info("starting application")
 
-	storage, err := postgres.NewStoragePostgres(cfg)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Endpoint, cfg.Tracing.SampleRatio)
 	if err != nil {
-		log.Error("failed to connect to database", "error", err)
+		log.Error("failed to setup tracing", "error", err)
 		os.Exit(1)
 	}
-	defer storage.Close()
 
-	log.Info("connected to database")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(cfg, log, os.Args[2:])
+
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("failed to flush spans", "error", err)
+		}
+
+		if err != nil {
+			log.Error("failed to run migrations", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	storage, err := setupStorage(cfg, log)
+	if err != nil {
+		log.Error("failed to setup storage", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.ExchangeRatesFile != "" {
+		loaded, err := rates.LoadFile(context.Background(), storage, cfg.ExchangeRatesFile)
+		if err != nil {
+			log.Error("failed to load exchange rates", "error", err)
+			storage.Close()
+			os.Exit(1)
+		}
+		log.Info("exchange rates loaded", "count", loaded)
+	}
+
+	verifier, err := setupVerifier(cfg)
+	if err != nil {
+		log.Error("failed to setup JWT verifier", "error", err)
+		storage.Close()
+		os.Exit(1)
+	}
+
+	readiness := &health.Readiness{}
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/subscriptions", subscriptions.NewSubscriptionsHandler(log, storage))
-	mux.HandleFunc("/subscriptions/", subscriptions.NewSubscriptionByIDHandler(log, storage))
-	mux.HandleFunc("/subscriptions/sum", sum.NewSumHandler(log, storage))
+	rateLimitStore := ratelimit.NewMemoryStore()
+
+	// API handlers limit request rate of the client and check its role against routePolicy
+	authorize := func(h http.Handler) http.Handler {
+		h = middleware.NewPolicyMiddleware(h, routePolicy)
+		if cfg.RateLimit.Enabled {
+			h = middleware.NewRateLimitMiddleware(h, log, rateLimitStore, cfg.RateLimit.Default, cfg.RateLimit.Routes)
+		}
+		return h
+	}
+
+	mux.Handle("/subscriptions", authorize(middleware.NewIdempotencyMiddleware(
+		subscriptions.NewSubscriptionsHandler(log, storage), log, storage, cfg.IdempotencyTTL,
+	)))
+	mux.Handle("/subscriptions/", authorize(subscriptions.NewSubscriptionByIDHandler(log, storage)))
+	mux.Handle("/subscriptions/sum", authorize(sum.NewSumHandler(log, storage)))
+	mux.Handle("/subscriptions/breakdown", authorize(breakdown.NewBreakdownHandler(log, storage)))
+	mux.Handle("/subscriptions/export", authorize(csvio.NewExportHandler(log, storage, cfg.HTTPServer.Timeout)))
+	mux.Handle("/subscriptions/import", authorize(csvio.NewImportHandler(log, storage)))
+	mux.Handle("/subscriptions/batch", authorize(middleware.NewIdempotencyMiddleware(
+		batch.NewBatchHandler(log, storage), log, storage, cfg.IdempotencyTTL,
+	)))
+	mux.Handle("/exchange-rates", authorize(rates.NewExchangeRatesHandler(log, storage)))
+	mux.Handle("/api-keys", authorize(apikeys.NewAPIKeysHandler(log, storage)))
+	mux.Handle("/api-keys/", authorize(apikeys.NewAPIKeyByIDHandler(log, storage)))
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", health.NewLivenessHandler())
+	mux.HandleFunc("/readyz", health.NewReadinessHandler(log, readiness, storage, cfg.HTTPServer.HealthCheckTimeout))
 
 	var handler http.Handler = mux
-	handler = middleware.NewLoggingMiddleware(handler, log)
+	handler = middleware.NewRouteMiddleware(handler)
+	if cfg.Auth.Enabled {
+		handler = middleware.NewAuthMiddleware(handler, log, storage, verifier, cfg.Auth.AdminKey, cfg.Auth.PublicPaths...)
+	} else {
+		log.Warn("authentication is disabled")
+	}
+	if cfg.RateLimit.Enabled {
+		handler = middleware.NewIPRateLimitMiddleware(handler, log, rateLimitStore, cfg.RateLimit.IP, "/healthz", "/readyz", "/metrics")
+	}
+	handler = middleware.NewMetricsMiddleware(handler)
+	handler = middleware.NewLoggingMiddleware(handler, log, "/healthz", "/readyz", "/metrics")
 	handler = middleware.NewRequestIDMiddleware(handler)
+	handler = middleware.NewTracingMiddleware(handler)
 	handler = middleware.NewRecovererMiddleware(handler)
 
 	log.Info("starting server")
@@ -66,14 +167,193 @@ func main() {
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	readiness.SetReady(true)
+
+	go purge.Run(ctx, log, storage, cfg.PurgeInterval, cfg.DeletedRetention)
+
+	select {
+	case <-ctx.Done():
+		log.Info("shutdown signal received")
+	case err := <-serverErr:
 		log.Error("failed to start server", "error", err)
 	}
+
+	shutdown(log, cfg, srv, storage, readiness)
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("failed to flush spans", "error", err)
+	}
+}
+
+// routePolicy is the minimal role required for each route, routes missing here are available to admins only
+var routePolicy = middleware.Policy{
+	"GET /subscriptions":               domain.RoleViewer,
+	"POST /subscriptions":              domain.RoleEditor,
+	"GET /subscriptions/{id}":          domain.RoleViewer,
+	"PATCH /subscriptions/{id}":        domain.RoleEditor,
+	"DELETE /subscriptions/{id}":       domain.RoleEditor,
+	"POST /subscriptions/{id}/restore": domain.RoleEditor,
+	"GET /subscriptions/{id}/history":  domain.RoleViewer,
+	"DELETE /subscriptions/{id}/purge": domain.RoleAdmin,
+	"GET /subscriptions/sum":           domain.RoleViewer,
+	"GET /subscriptions/breakdown":     domain.RoleViewer,
+	"GET /subscriptions/export":        domain.RoleViewer,
+	"POST /subscriptions/import":       domain.RoleEditor,
+	"POST /subscriptions/batch":        domain.RoleEditor,
+	"GET /exchange-rates":              domain.RoleViewer,
+	"PUT /exchange-rates":              domain.RoleEditor,
+	"GET /api-keys":                    domain.RoleAdmin,
+	"POST /api-keys":                   domain.RoleAdmin,
+	"DELETE /api-keys/{id}":            domain.RoleAdmin,
+}
+
+// shutdown stops accepting traffic, drains in-flight requests and closes storage
+func shutdown(log *slog.Logger, cfg *config.Config, srv *http.Server, storage storage, readiness *health.Readiness) {
+	readiness.SetReady(false)
+	log.Info("marked application as not ready")
+
+	if cfg.HTTPServer.ShutdownDelay > 0 {
+		log.Info("waiting for load balancers to stop routing", "delay", cfg.HTTPServer.ShutdownDelay.String())
+		time.Sleep(cfg.HTTPServer.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	log.Info("stopping server", "timeout", cfg.HTTPServer.ShutdownTimeout.String())
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error("failed to gracefully stop server", "error", err)
+	} else {
+		log.Info("server stopped")
+	}
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", "error", err)
+	} else {
+		log.Info("storage closed")
+	}
+}
+
+// storage is a subscriptions repository owning underlying resources
+type storage interface {
+	handlers.SubscriptionRepository
+	handlers.ExchangeRateRepository
+	handlers.IdempotencyRepository
+	handlers.APIKeyRepository
+	purge.Repository
+	health.Checker
+	Close() error
+}
+
+func setupStorage(cfg *config.Config, log *slog.Logger) (storage, error) {
+	if cfg.StorageDriver == config.StorageDriverMemory {
+		log.Info("using in-memory storage")
+		return memory.NewStorageMemory(), nil
+	}
+
+	pg, err := postgres.NewStoragePostgres(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	log.Info("connected to database")
+
+	if cfg.Postgres.AutoMigrate {
+		applied, err := pg.MigrateUp(context.Background())
+		if err != nil {
+			pg.Close()
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		log.Info("migrations applied", "count", applied)
+	}
+
+	return pg, nil
+}
+
+// setupVerifier creates verifier of user tokens, nil if neither HS256 secret nor JWKS file is configured
+func setupVerifier(cfg *config.Config) (*jwt.Verifier, error) {
+	if cfg.Auth.JWTSecret == "" && cfg.Auth.JWTJWKSFile == "" {
+		return nil, nil
+	}
+
+	var rsaKeys map[string]*rsa.PublicKey
+
+	if cfg.Auth.JWTJWKSFile != "" {
+		keys, err := jwt.LoadJWKSFile(cfg.Auth.JWTJWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		rsaKeys = keys
+	}
+
+	verifier := jwt.NewVerifier([]byte(cfg.Auth.JWTSecret), rsaKeys, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience)
+
+	return verifier, nil
+}
+
+// runMigrate executes "migrate up|down|status" subcommand
+func runMigrate(cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: migrate up|down|status")
+	}
+
+	if cfg.StorageDriver != config.StorageDriverPostgres {
+		return fmt.Errorf("migrations are not supported by %s storage", cfg.StorageDriver)
+	}
+
+	storage, err := postgres.NewStoragePostgres(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := storage.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("migrations applied", "count", applied)
+	case "down":
+		version, err := storage.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("migration rolled back", "version", version)
+	case "status":
+		status, err := storage.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		pending := make([]string, 0, len(status.Pending))
+		for _, m := range status.Pending {
+			pending = append(pending, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+		log.Info("migration status", "version", status.Version, "dirty", status.Dirty, "pending", pending)
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	return nil
 }
 
 func setupLogger() *slog.Logger {
 	logger := slog.New(
-		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		tracing.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})),
 	)
 
 	return logger
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+)
+
+const testID = "550e8400-e29b-41d4-a716-446655440000"
+
+// policyMatrix lists whether viewer, editor and admin may call each route
+var policyMatrix = []struct {
+	route                 string
+	viewer, editor, admin bool
+}{
+	{"GET /subscriptions", true, true, true},
+	{"POST /subscriptions", false, true, true},
+	{"GET /subscriptions/{id}", true, true, true},
+	{"PATCH /subscriptions/{id}", false, true, true},
+	{"DELETE /subscriptions/{id}", false, true, true},
+	{"POST /subscriptions/{id}/restore", false, true, true},
+	{"GET /subscriptions/{id}/history", true, true, true},
+	{"DELETE /subscriptions/{id}/purge", false, false, true},
+	{"GET /subscriptions/sum", true, true, true},
+	{"GET /subscriptions/breakdown", true, true, true},
+	{"GET /subscriptions/export", true, true, true},
+	{"POST /subscriptions/import", false, true, true},
+	{"POST /subscriptions/batch", false, true, true},
+	{"GET /exchange-rates", true, true, true},
+	{"PUT /exchange-rates", false, true, true},
+	{"GET /api-keys", false, false, true},
+	{"POST /api-keys", false, false, true},
+	{"DELETE /api-keys/{id}", false, false, true},
+
+	// routes missing in the policy are available to admins only
+	{"PUT /subscriptions/{id}", false, false, true},
+	{"DELETE /subscriptions", false, false, true},
+	{"GET /subscriptions/{id}/purge", false, false, true},
+	{"DELETE /exchange-rates", false, false, true},
+	{"GET /api-keys/{id}", false, false, true},
+}
+
+// newPolicyTestMux registers API patterns of main with handlers responding 200 behind the policy middleware
+func newPolicyTestMux() *http.ServeMux {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux := http.NewServeMux()
+	for _, pattern := range []string{
+		"/subscriptions", "/subscriptions/", "/subscriptions/sum", "/subscriptions/breakdown",
+		"/subscriptions/export", "/subscriptions/import", "/subscriptions/batch",
+		"/exchange-rates", "/api-keys", "/api-keys/",
+	} {
+		mux.Handle(pattern, middleware.NewPolicyMiddleware(ok, routePolicy))
+	}
+
+	return mux
+}
+
+func serveAs(mux *http.ServeMux, method, path string, role domain.Role) int {
+	r := httptest.NewRequest(method, path, nil)
+	if role != "" {
+		r = r.WithContext(middleware.WithIdentity(r.Context(), domain.Identity{Subject: "test", Role: role}))
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	return w.Code
+}
+
+func TestRoutePolicy(t *testing.T) {
+	mux := newPolicyTestMux()
+
+	for _, tc := range policyMatrix {
+		method, route, _ := strings.Cut(tc.route, " ")
+		path := strings.ReplaceAll(route, "{id}", testID)
+
+		for _, c := range []struct {
+			role    domain.Role
+			allowed bool
+		}{
+			{"", true},
+			{domain.RoleViewer, tc.viewer},
+			{domain.RoleEditor, tc.editor},
+			{domain.RoleAdmin, tc.admin},
+		} {
+			want := http.StatusForbidden
+			if c.allowed {
+				want = http.StatusOK
+			}
+
+			if got := serveAs(mux, method, path, c.role); got != want {
+				t.Errorf("%s as %q: got status %d, want %d", tc.route, c.role, got, want)
+			}
+		}
+	}
+}
+
+func TestRoutePolicyCoversMatrix(t *testing.T) {
+	listed := make(map[string]bool, len(policyMatrix))
+	for _, tc := range policyMatrix {
+		listed[tc.route] = true
+	}
+
+	for route := range routePolicy {
+		if !listed[route] {
+			t.Errorf("route %s of the policy is missing in the test matrix", route)
+		}
+	}
+}
+
+func TestRoutePolicyMalformedID(t *testing.T) {
+	mux := newPolicyTestMux()
+
+	// malformed IDs are templated as {id}, so the route's role applies and the handler validates the ID
+	for _, tc := range []struct {
+		method, path string
+		role         domain.Role
+		want         int
+	}{
+		{http.MethodGet, "/subscriptions/abc", domain.RoleViewer, http.StatusOK},
+		{http.MethodPatch, "/subscriptions/abc", domain.RoleEditor, http.StatusOK},
+		{http.MethodGet, "/subscriptions/abc/history", domain.RoleViewer, http.StatusOK},
+		{http.MethodDelete, "/subscriptions/abc/purge", domain.RoleEditor, http.StatusForbidden},
+		{http.MethodDelete, "/api-keys/abc", domain.RoleEditor, http.StatusForbidden},
+	} {
+		if got := serveAs(mux, tc.method, tc.path, tc.role); got != tc.want {
+			t.Errorf("%s %s as %q: got status %d, want %d", tc.method, tc.path, tc.role, got, tc.want)
+		}
+	}
+}
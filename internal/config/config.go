@@ -3,18 +3,58 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/ratelimit"
+)
+
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverMemory   = "memory"
 )
 
+// minAdminKeyLength is the minimal length of ADMIN_API_KEY
+const minAdminKeyLength = 16
+
+// placeholderAdminKeys are sample values of ADMIN_API_KEY which must not be used
+var placeholderAdminKeys = []string{"change-me", "changeme", "secret", "admin", "password"}
+
 type Config struct {
+	// StorageDriver selects subscriptions storage: postgres or memory
+	StorageDriver string
+
+	// ExchangeRatesFile is an optional JSON file with exchange rates loaded on startup
+	ExchangeRatesFile string
+
+	// IdempotencyTTL is how long responses to requests with Idempotency-Key are replayed
+	IdempotencyTTL time.Duration
+
+	// DeletedRetention is how long soft deleted subscriptions are kept before purge, 0 disables purging
+	DeletedRetention time.Duration
+	// PurgeInterval is how often soft deleted subscriptions are purged and expired idempotency keys are deleted
+	PurgeInterval time.Duration
+
 	HTTPServer
 	Postgres
+	Auth
+	RateLimit
+	Tracing
 }
 
 type HTTPServer struct {
 	ServerAddress string
 	Timeout       time.Duration
 	IdleTimeout   time.Duration
+
+	// ShutdownDelay is time between marking application as not ready and stopping the server
+	ShutdownDelay time.Duration
+	// ShutdownTimeout limits time of draining in-flight requests on shutdown
+	ShutdownTimeout time.Duration
+
+	// HealthCheckTimeout limits time of database check in readiness probe
+	HealthCheckTimeout time.Duration
 }
 
 type Postgres struct {
@@ -23,32 +63,58 @@ type Postgres struct {
 	DB       string
 	User     string
 	Password string
+
+	// AutoMigrate applies pending schema migrations on startup
+	AutoMigrate bool
 }
 
-func MustLoadConfig() *Config {
-	pgDb := os.Getenv("POSTGRES_DB")
-	if pgDb == "" {
-		log.Fatal("POSTGRES_DB environment variable not set")
-	}
+type Auth struct {
+	// Enabled requires API key on every request except ones to PublicPaths
+	Enabled bool
+	// AdminKey is an optional static admin API key, e.g. to create the first keys
+	AdminKey string
+	// PublicPaths are accessible without API key, paths ending with slash match all paths under them
+	PublicPaths []string
 
-	pgHost := os.Getenv("POSTGRES_HOST")
-	if pgHost == "" {
-		log.Fatal("POSTGRES_HOST environment variable not set")
-	}
+	// JWTSecret is HMAC secret of HS256 user tokens, empty disables HS256
+	JWTSecret string
+	// JWTJWKSFile is an optional JWKS file with RSA keys of RS256 user tokens
+	JWTJWKSFile string
+	// JWTIssuer and JWTAudience are required values of iss and aud claims, empty values are not checked
+	JWTIssuer   string
+	JWTAudience string
+}
 
-	pgPort := os.Getenv("POSTGRES_PORT")
-	if pgPort == "" {
-		log.Fatal("POSTGRES_PORT environment variable not set")
+type RateLimit struct {
+	Enabled bool
+	// Default limits requests of a client to routes without a limit of their own
+	Default ratelimit.Limit
+	// Routes are limits of "METHOD route" with separate buckets, e.g. "GET /subscriptions"
+	Routes map[string]ratelimit.Limit
+	// IP limits requests from an IP address before authentication, including ones with invalid keys
+	IP ratelimit.Limit
+}
+
+type Tracing struct {
+	// Endpoint is OTLP/HTTP endpoint of the collector, empty disables export of spans
+	Endpoint string
+	// SampleRatio is a share of traces started by the service which are sampled
+	SampleRatio float64
+}
+
+func MustLoadConfig() *Config {
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	if storageDriver == "" {
+		storageDriver = StorageDriverPostgres
 	}
 
-	pgUser := os.Getenv("POSTGRES_USER")
-	if pgUser == "" {
-		log.Fatal("POSTGRES_USER environment variable not set")
+	if storageDriver != StorageDriverPostgres && storageDriver != StorageDriverMemory {
+		log.Fatalf("invalid STORAGE_DRIVER: %q, expected %s or %s", storageDriver, StorageDriverPostgres, StorageDriverMemory)
 	}
 
-	pgPassword := os.Getenv("POSTGRES_PASSWORD")
-	if pgPassword == "" {
-		log.Fatal("POSTGRES_PASSWORD environment variable not set")
+	var pg Postgres
+	if storageDriver == StorageDriverPostgres {
+		pg = mustLoadPostgres()
 	}
 
 	serverAddress := os.Getenv("SERVER_ADDRESS")
@@ -82,10 +148,254 @@ func MustLoadConfig() *Config {
 		log.Fatalf("invalid SERVER_IDLE_TIMEOUT: %v", err)
 	}
 
+	shutdownDelayStr := os.Getenv("SERVER_SHUTDOWN_DELAY")
+	if shutdownDelayStr == "" {
+		shutdownDelayStr = "0s"
+	}
+	shutdownDelay, err := time.ParseDuration(shutdownDelayStr)
+	if err != nil {
+		log.Fatalf("invalid SERVER_SHUTDOWN_DELAY: %v", err)
+	}
+
+	shutdownTimeoutStr := os.Getenv("SERVER_SHUTDOWN_TIMEOUT")
+	if shutdownTimeoutStr == "" {
+		shutdownTimeoutStr = "10s"
+	}
+	shutdownTimeout, err := time.ParseDuration(shutdownTimeoutStr)
+	if err != nil {
+		log.Fatalf("invalid SERVER_SHUTDOWN_TIMEOUT: %v", err)
+	}
+
+	healthCheckTimeoutStr := os.Getenv("HEALTH_CHECK_TIMEOUT")
+	if healthCheckTimeoutStr == "" {
+		healthCheckTimeoutStr = "2s"
+	}
+	healthCheckTimeout, err := time.ParseDuration(healthCheckTimeoutStr)
+	if err != nil {
+		log.Fatalf("invalid HEALTH_CHECK_TIMEOUT: %v", err)
+	}
+
 	srv := HTTPServer{
-		ServerAddress: serverAddress,
-		Timeout:       timeout,
-		IdleTimeout:   idleTimeout,
+		ServerAddress:   serverAddress,
+		Timeout:         timeout,
+		IdleTimeout:     idleTimeout,
+		ShutdownDelay:   shutdownDelay,
+		ShutdownTimeout: shutdownTimeout,
+
+		HealthCheckTimeout: healthCheckTimeout,
+	}
+
+	idempotencyTTLStr := os.Getenv("IDEMPOTENCY_TTL")
+	if idempotencyTTLStr == "" {
+		idempotencyTTLStr = "24h"
+	}
+	idempotencyTTL, err := time.ParseDuration(idempotencyTTLStr)
+	if err != nil {
+		log.Fatalf("invalid IDEMPOTENCY_TTL: %v", err)
+	}
+
+	deletedRetentionStr := os.Getenv("DELETED_RETENTION")
+	if deletedRetentionStr == "" {
+		deletedRetentionStr = "720h"
+	}
+	deletedRetention, err := time.ParseDuration(deletedRetentionStr)
+	if err != nil || deletedRetention < 0 {
+		log.Fatalf("invalid DELETED_RETENTION: %q", deletedRetentionStr)
+	}
+
+	purgeIntervalStr := os.Getenv("PURGE_INTERVAL")
+	if purgeIntervalStr == "" {
+		purgeIntervalStr = "1h"
+	}
+	purgeInterval, err := time.ParseDuration(purgeIntervalStr)
+	if err != nil || purgeInterval <= 0 {
+		log.Fatalf("invalid PURGE_INTERVAL: %q", purgeIntervalStr)
+	}
+
+	auth := mustLoadAuth()
+
+	rateLimit := mustLoadRateLimit()
+
+	sampleRatio := 1.0
+	if sampleRatioStr := os.Getenv("TRACING_SAMPLE_RATIO"); sampleRatioStr != "" {
+		v, err := strconv.ParseFloat(sampleRatioStr, 64)
+		if err != nil || v < 0 || v > 1 {
+			log.Fatalf("invalid TRACING_SAMPLE_RATIO: %q, expected number from 0 to 1", sampleRatioStr)
+		}
+		sampleRatio = v
+	}
+
+	tracing := Tracing{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		SampleRatio: sampleRatio,
+	}
+
+	cfg := Config{
+		StorageDriver:     storageDriver,
+		ExchangeRatesFile: os.Getenv("EXCHANGE_RATES_FILE"),
+		IdempotencyTTL:    idempotencyTTL,
+		DeletedRetention:  deletedRetention,
+		PurgeInterval:     purgeInterval,
+		HTTPServer:        srv,
+		Postgres:          pg,
+		Auth:              auth,
+		RateLimit:         rateLimit,
+		Tracing:           tracing,
+	}
+
+	return &cfg
+}
+
+func mustLoadAuth() Auth {
+	enabled := true
+	if enabledStr := os.Getenv("AUTH_ENABLED"); enabledStr != "" {
+		v, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			log.Fatalf("invalid AUTH_ENABLED: %v", err)
+		}
+		enabled = v
+	}
+
+	publicPathsStr, ok := os.LookupEnv("AUTH_PUBLIC_PATHS")
+	if !ok {
+		publicPathsStr = "/healthz,/readyz,/metrics,/swagger/"
+	}
+
+	publicPaths := make([]string, 0)
+	for _, path := range strings.Split(publicPathsStr, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			log.Fatalf("invalid AUTH_PUBLIC_PATHS: path %q must start with /", path)
+		}
+		publicPaths = append(publicPaths, path)
+	}
+
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey != "" {
+		for _, placeholder := range placeholderAdminKeys {
+			if strings.EqualFold(strings.TrimSpace(adminKey), placeholder) {
+				log.Fatalf("invalid ADMIN_API_KEY: placeholder value %q must be replaced", adminKey)
+			}
+		}
+		if len(adminKey) < minAdminKeyLength {
+			log.Fatalf("invalid ADMIN_API_KEY: must be at least %d characters long", minAdminKeyLength)
+		}
+	}
+
+	auth := Auth{
+		Enabled:     enabled,
+		AdminKey:    adminKey,
+		PublicPaths: publicPaths,
+
+		JWTSecret:   os.Getenv("JWT_SECRET"),
+		JWTJWKSFile: os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:   os.Getenv("JWT_ISSUER"),
+		JWTAudience: os.Getenv("JWT_AUDIENCE"),
+	}
+
+	return auth
+}
+
+func mustLoadRateLimit() RateLimit {
+	enabled := true
+	if enabledStr := os.Getenv("RATE_LIMIT_ENABLED"); enabledStr != "" {
+		v, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			log.Fatalf("invalid RATE_LIMIT_ENABLED: %v", err)
+		}
+		enabled = v
+	}
+
+	defaultLimitStr := os.Getenv("RATE_LIMIT")
+	if defaultLimitStr == "" {
+		defaultLimitStr = "600/1m"
+	}
+	defaultLimit, err := ratelimit.ParseLimit(defaultLimitStr)
+	if err != nil {
+		log.Fatalf("invalid RATE_LIMIT: %v", err)
+	}
+
+	routesStr, ok := os.LookupEnv("RATE_LIMIT_ROUTES")
+	if !ok {
+		routesStr = "GET /subscriptions=120/1m,GET /subscriptions/export=10/1m"
+	}
+
+	routes := make(map[string]ratelimit.Limit)
+	for _, routeLimit := range strings.Split(routesStr, ",") {
+		routeLimit = strings.TrimSpace(routeLimit)
+		if routeLimit == "" {
+			continue
+		}
+
+		route, limitStr, ok := strings.Cut(routeLimit, "=")
+		method, path, hasPath := strings.Cut(route, " ")
+		if !ok || !hasPath || method == "" || !strings.HasPrefix(path, "/") {
+			log.Fatalf("invalid RATE_LIMIT_ROUTES: %q, expected METHOD /route=requests/period", routeLimit)
+		}
+
+		limit, err := ratelimit.ParseLimit(limitStr)
+		if err != nil {
+			log.Fatalf("invalid RATE_LIMIT_ROUTES: %v", err)
+		}
+
+		routes[route] = limit
+	}
+
+	ipLimitStr := os.Getenv("RATE_LIMIT_IP")
+	if ipLimitStr == "" {
+		ipLimitStr = "1200/1m"
+	}
+	ipLimit, err := ratelimit.ParseLimit(ipLimitStr)
+	if err != nil {
+		log.Fatalf("invalid RATE_LIMIT_IP: %v", err)
+	}
+
+	rateLimit := RateLimit{
+		Enabled: enabled,
+		Default: defaultLimit,
+		Routes:  routes,
+		IP:      ipLimit,
+	}
+
+	return rateLimit
+}
+
+func mustLoadPostgres() Postgres {
+	pgDb := os.Getenv("POSTGRES_DB")
+	if pgDb == "" {
+		log.Fatal("POSTGRES_DB environment variable not set")
+	}
+
+	pgHost := os.Getenv("POSTGRES_HOST")
+	if pgHost == "" {
+		log.Fatal("POSTGRES_HOST environment variable not set")
+	}
+
+	pgPort := os.Getenv("POSTGRES_PORT")
+	if pgPort == "" {
+		log.Fatal("POSTGRES_PORT environment variable not set")
+	}
+
+	pgUser := os.Getenv("POSTGRES_USER")
+	if pgUser == "" {
+		log.Fatal("POSTGRES_USER environment variable not set")
+	}
+
+	pgPassword := os.Getenv("POSTGRES_PASSWORD")
+	if pgPassword == "" {
+		log.Fatal("POSTGRES_PASSWORD environment variable not set")
+	}
+
+	autoMigrate := false
+	if autoMigrateStr := os.Getenv("AUTO_MIGRATE"); autoMigrateStr != "" {
+		v, err := strconv.ParseBool(autoMigrateStr)
+		if err != nil {
+			log.Fatalf("invalid AUTO_MIGRATE: %v", err)
+		}
+		autoMigrate = v
 	}
 
 	pg := Postgres{
@@ -94,12 +404,9 @@ func MustLoadConfig() *Config {
 		DB:       pgDb,
 		User:     pgUser,
 		Password: pgPassword,
-	}
 
-	cfg := Config{
-		HTTPServer: srv,
-		Postgres:   pg,
+		AutoMigrate: autoMigrate,
 	}
 
-	return &cfg
+	return pg
 }
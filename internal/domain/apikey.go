@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// APIKeyTokenPrefix marks tokens issued by the service, so that leaked keys are easy to find
+	APIKeyTokenPrefix = "sm_"
+
+	apiKeyTokenBytes = 32
+	// apiKeyDisplayLength is the number of leading token characters stored in plain text to identify the key
+	apiKeyDisplayLength = 11
+
+	maxAPIKeyNameLength = 100
+)
+
+// APIKey is a client credential, only SHA-256 hash of the token is stored
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name       string     `json:"name" db:"name" example:"billing-service"`
+	Prefix     string     `json:"prefix" db:"prefix" example:"sm_Yq3kT9vA"`
+	Role       Role       `json:"role" db:"role" example:"editor" enums:"viewer,editor,admin"`
+	Hash       string     `json:"-" db:"key_hash"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at" example:"2025-01-01T12:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at" example:"2025-01-02T08:30:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// CreateAPIKeyInput represents API key creation request
+type CreateAPIKeyInput struct {
+	Name string `json:"name" example:"billing-service"`
+	// Role of the key, editor by default
+	Role Role `json:"role,omitempty" example:"editor" enums:"viewer,editor,admin"`
+
+	// Prefix and Hash of the token are filled by GenerateToken
+	Prefix string `json:"-"`
+	Hash   string `json:"-"`
+}
+
+// CreatedAPIKey is returned once on creation, the token can not be retrieved later
+type CreatedAPIKey struct {
+	APIKey
+	Token string `json:"token" example:"sm_Yq3kT9vAb7pXn2LwQe5RmZs8UcHd4GfJ1oKiTaVyB0E"`
+}
+
+// Identity is the authenticated client of the request
+type Identity struct {
+	// Subject identifies the client in logs and subscription history, e.g. api_key:<id> or user:<id>
+	Subject string
+	Role    Role
+	// UserID is set for end users authenticated with JWT, they may access only their subscriptions.
+	// It is nil for staff and API keys of services.
+	UserID *uuid.UUID
+}
+
+// ScopedUserID returns the user whose subscriptions only are accessible to the client
+func (i Identity) ScopedUserID() (uuid.UUID, bool) {
+	if i.UserID == nil {
+		return uuid.Nil, false
+	}
+
+	return *i.UserID, true
+}
+
+// SetDefaults sets editor role if it is not set
+func (in *CreateAPIKeyInput) SetDefaults() {
+	if in.Role == "" {
+		in.Role = RoleEditor
+	}
+}
+
+// Validate returns all violations of API key input, nil if input is valid.
+// Defaults must be set before validation.
+func (in CreateAPIKeyInput) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	name := strings.TrimSpace(in.Name)
+
+	if name == "" {
+		errs.Add("name", CodeRequired, "name is required")
+	} else if len(name) > maxAPIKeyNameLength {
+		errs.Add("name", CodeRange, "name must not be longer than 100 characters")
+	}
+
+	if !in.Role.IsValid() {
+		errs.Add("role", CodeInvalid, "role must be one of viewer, editor, admin")
+	}
+
+	return errs
+}
+
+// GenerateToken generates a random token of the key and fills its prefix and hash
+func (in *CreateAPIKeyInput) GenerateToken() (string, error) {
+	b := make([]byte, apiKeyTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	token := APIKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	in.Prefix = token[:apiKeyDisplayLength]
+	in.Hash = HashAPIKey(token)
+
+	return token, nil
+}
+
+// HashAPIKey returns hex SHA-256 of the token. Tokens are random, so a fast hash without salt is enough
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
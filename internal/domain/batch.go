@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+const (
+	// BatchModeAtomic applies all operations of the batch or none of them
+	BatchModeAtomic = "atomic"
+	// BatchModePerItem applies every successful operation regardless of others
+	BatchModePerItem = "per_item"
+)
+
+// MaxBatchSize limits number of operations in a batch
+const MaxBatchSize = 100
+
+// BatchRequest is a list of subscription operations executed in a single transaction
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty" example:"atomic" enums:"atomic,per_item"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is a single operation of the batch. Data is CreateSubscriptionInput for create
+// and UpdateSubscriptionInput for update, ID is required for update and delete.
+type BatchOperation struct {
+	Op      string          `json:"op" example:"create" enums:"create,update,delete"`
+	ID      *uuid.UUID      `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Version *int            `json:"version,omitempty" example:"1"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// BatchItem is a parsed and validated batch operation
+type BatchItem struct {
+	Op     string
+	ID     uuid.UUID
+	Create CreateSubscriptionInput
+	Update UpdateSubscriptionInput
+}
+
+// BatchItemResult is the result of a batch item, subscription is nil for delete and failed items
+type BatchItemResult struct {
+	Subscription *Subscription
+	Err          error
+}
+
+// ParseItem decodes and validates the operation
+func (op BatchOperation) ParseItem() (BatchItem, ValidationErrors) {
+	item := BatchItem{Op: op.Op}
+
+	var errs ValidationErrors
+
+	switch op.Op {
+	case BatchOpCreate:
+		if err := json.Unmarshal(op.Data, &item.Create); err != nil {
+			errs.Add("data", CodeInvalid, "data must be create subscription input")
+			return item, errs
+		}
+		item.Create.SetDefaults()
+		errs = item.Create.Validate()
+	case BatchOpUpdate:
+		if err := json.Unmarshal(op.Data, &item.Update); err != nil {
+			errs.Add("data", CodeInvalid, "data must be update subscription input")
+			return item, errs
+		}
+		item.Update.ExpectedVersion = op.Version
+		errs = item.Update.Validate()
+	case BatchOpDelete:
+	default:
+		errs.Add("op", CodeInvalid, "op must be one of create, update, delete")
+		return item, errs
+	}
+
+	if op.Op != BatchOpCreate {
+		if op.ID == nil || *op.ID == uuid.Nil {
+			errs.Add("id", CodeRequired, "id is required")
+		} else {
+			item.ID = *op.ID
+		}
+	}
+
+	return item, errs
+}
@@ -0,0 +1,62 @@
+package domain
+
+// BillingPeriod is the period subscription price is charged for
+type BillingPeriod string
+
+const (
+	BillingPeriodWeekly    BillingPeriod = "weekly"
+	BillingPeriodMonthly   BillingPeriod = "monthly"
+	BillingPeriodQuarterly BillingPeriod = "quarterly"
+	BillingPeriodYearly    BillingPeriod = "yearly"
+)
+
+const DefaultBillingInterval = 1
+
+// IsValid reports whether billing period is one of the supported ones
+func (p BillingPeriod) IsValid() bool {
+	switch p {
+	case BillingPeriodWeekly, BillingPeriodMonthly, BillingPeriodQuarterly, BillingPeriodYearly:
+		return true
+	}
+	return false
+}
+
+// perMonth returns share of the billing period in one month as a fraction
+func (p BillingPeriod) perMonth() (num, den int) {
+	switch p {
+	case BillingPeriodWeekly:
+		return 52, 12
+	case BillingPeriodQuarterly:
+		return 1, 3
+	case BillingPeriodYearly:
+		return 1, 12
+	default:
+		return 1, 1
+	}
+}
+
+// ProratedCost returns cost of months of subscription with price charged every interval billing periods,
+// rounded to the nearest integer. E.g. 5988 per year costs 499 per month.
+func ProratedCost(price int, period BillingPeriod, interval int, months int) int {
+	if interval < 1 {
+		interval = DefaultBillingInterval
+	}
+
+	num, den := period.perMonth()
+
+	a := int64(price) * int64(months) * int64(num)
+	b := int64(den) * int64(interval)
+
+	return int((2*a + b) / (2 * b))
+}
+
+// proratedCost returns cost of months of subscription without rounding
+func proratedCost(price int, period BillingPeriod, interval int, months int) float64 {
+	if interval < 1 {
+		interval = DefaultBillingInterval
+	}
+
+	num, den := period.perMonth()
+
+	return float64(price) * float64(months) * float64(num) / float64(den*interval)
+}
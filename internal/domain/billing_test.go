@@ -0,0 +1,39 @@
+package domain
+
+import "testing"
+
+func TestProratedCost(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		price    int
+		period   BillingPeriod
+		interval int
+		months   int
+		want     int
+	}{
+		{"monthly", 499, BillingPeriodMonthly, 1, 3, 1497},
+		{"no months", 499, BillingPeriodMonthly, 1, 0, 0},
+		{"yearly per month", 5988, BillingPeriodYearly, 1, 1, 499},
+		{"yearly for the whole year", 5988, BillingPeriodYearly, 1, 12, 5988},
+		{"quarterly rounds down", 100, BillingPeriodQuarterly, 1, 1, 33},
+		{"quarterly rounds up", 100, BillingPeriodQuarterly, 1, 2, 67},
+		{"half rounds up", 1, BillingPeriodYearly, 1, 6, 1},
+		{"below half rounds down", 1, BillingPeriodYearly, 1, 5, 0},
+		{"every two months half rounds up", 999, BillingPeriodMonthly, 2, 3, 1499},
+		{"zero interval is one period", 499, BillingPeriodMonthly, 0, 2, 998},
+		{"negative interval is one period", 5988, BillingPeriodYearly, -1, 1, 499},
+
+		// a month has 52/12 weeks
+		{"weekly per month", 120, BillingPeriodWeekly, 1, 1, 520},
+		{"weekly per month rounds", 100, BillingPeriodWeekly, 1, 1, 433},
+		{"weekly for a quarter", 100, BillingPeriodWeekly, 1, 3, 1300},
+		{"every two weeks", 240, BillingPeriodWeekly, 2, 1, 520},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ProratedCost(tc.price, tc.period, tc.interval, tc.months); got != tc.want {
+				t.Errorf("ProratedCost(%d, %s, %d, %d) = %d, want %d",
+					tc.price, tc.period, tc.interval, tc.months, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BreakdownGroupByMonth   = "month"
+	BreakdownGroupByService = "service"
+)
+
+// MaxBreakdownMonths limits the breakdown period
+const MaxBreakdownMonths = 120
+
+// BreakdownFilter selects subscriptions and period of the cost breakdown
+type BreakdownFilter struct {
+	UserID      uuid.UUID
+	ServiceName *string
+	From        MonthYear
+	To          MonthYear
+	// GroupBy is month or service, buckets are split by service within months for the latter
+	GroupBy  string
+	Currency string
+}
+
+// MonthlyCharge is the charge of a subscription in a single month with the price effective in it
+type MonthlyCharge struct {
+	Month          MonthYear `db:"month"`
+	SubscriptionID uuid.UUID `db:"id"`
+	ServiceName    string    `db:"service_name"`
+	Price          int       `db:"price"`
+	Currency       string    `db:"currency"`
+
+	BillingPeriod   BillingPeriod `db:"billing_period"`
+	BillingInterval int           `db:"billing_interval"`
+}
+
+// BreakdownBucket is the total cost of subscriptions in a month, optionally of a single service
+type BreakdownBucket struct {
+	Month           MonthYear   `json:"month" example:"07-2025"`
+	ServiceName     string      `json:"service_name,omitempty" example:"Netflix"`
+	Amount          int         `json:"amount" example:"499"`
+	SubscriptionIDs []uuid.UUID `json:"subscription_ids"`
+
+	exact float64
+}
+
+// Breakdown is the time series of subscriptions cost
+type Breakdown struct {
+	Currency string            `json:"currency" example:"RUB"`
+	GroupBy  string            `json:"group_by" example:"month"`
+	Buckets  []BreakdownBucket `json:"buckets"`
+}
+
+// BuildBreakdown groups monthly charges into buckets converted to the filter currency.
+// Bucket amount is rounded after summing exact charges, months without charges are kept when grouping by month.
+func BuildBreakdown(charges []MonthlyCharge, in BreakdownFilter, rates *ExchangeRates) (*Breakdown, error) {
+	breakdown := Breakdown{Currency: in.Currency, GroupBy: in.GroupBy, Buckets: make([]BreakdownBucket, 0)}
+
+	type bucketKey struct {
+		month       int
+		serviceName string
+	}
+
+	buckets := make(map[bucketKey]*BreakdownBucket)
+	months := MonthsBetween(in.From, in.To)
+
+	if in.GroupBy != BreakdownGroupByService {
+		for i := 0; i < months; i++ {
+			buckets[bucketKey{month: i}] = &BreakdownBucket{Month: in.From.AddMonths(i), SubscriptionIDs: make([]uuid.UUID, 0)}
+		}
+	}
+
+	for _, charge := range charges {
+		key := bucketKey{month: MonthsBetween(in.From, charge.Month) - 1}
+		if key.month < 0 || key.month >= months {
+			continue
+		}
+
+		if in.GroupBy == BreakdownGroupByService {
+			key.serviceName = charge.ServiceName
+		}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &BreakdownBucket{Month: charge.Month, ServiceName: key.serviceName, SubscriptionIDs: make([]uuid.UUID, 0)}
+			buckets[key] = bucket
+		}
+
+		monthly := proratedCost(charge.Price, charge.BillingPeriod, charge.BillingInterval, 1)
+
+		amount, err := rates.Convert(monthly, charge.Currency, in.Currency, charge.Month)
+		if err != nil {
+			return nil, err
+		}
+
+		bucket.exact += amount
+
+		if !slices.Contains(bucket.SubscriptionIDs, charge.SubscriptionID) {
+			bucket.SubscriptionIDs = append(bucket.SubscriptionIDs, charge.SubscriptionID)
+		}
+	}
+
+	for _, bucket := range buckets {
+		bucket.Amount = int(math.Round(bucket.exact))
+		slices.SortFunc(bucket.SubscriptionIDs, func(a, b uuid.UUID) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		breakdown.Buckets = append(breakdown.Buckets, *bucket)
+	}
+
+	slices.SortFunc(breakdown.Buckets, func(a, b BreakdownBucket) int {
+		if c := a.Month.Time().Compare(b.Month.Time()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	})
+
+	return &breakdown, nil
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// DefaultCurrency is the currency of subscriptions created without currency.
+// Exchange rates are quoted in it, i.e. rate is the price of one unit of currency in DefaultCurrency.
+const DefaultCurrency = "RUB"
+
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// NoExchangeRateError reports that currency has no exchange rate valid in the month
+type NoExchangeRateError struct {
+	Currency string
+	Month    MonthYear
+}
+
+func (e *NoExchangeRateError) Error() string {
+	return fmt.Sprintf("%s for %s in %s", ErrNoExchangeRate, e.Currency, e.Month.Time().Format(MonthYearLayout))
+}
+
+func (e *NoExchangeRateError) Is(target error) bool {
+	return target == ErrNoExchangeRate
+}
+
+var currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsValidCurrency reports whether currency is an ISO 4217 alphabetic code
+func IsValidCurrency(currency string) bool {
+	return currencyRegexp.MatchString(currency)
+}
+
+// ExchangeRate is the price of one unit of currency in DefaultCurrency, valid from the month until the next rate
+type ExchangeRate struct {
+	Currency string    `json:"currency" db:"currency" example:"USD"`
+	Month    MonthYear `json:"month" db:"month" example:"01-2025"`
+	Rate     float64   `json:"rate" db:"rate" example:"92.5"`
+}
+
+// ExchangeRates converts amounts between currencies with rates valid in a given month
+type ExchangeRates struct {
+	byCurrency map[string][]ExchangeRate
+}
+
+func NewExchangeRates(rates []ExchangeRate) *ExchangeRates {
+	byCurrency := make(map[string][]ExchangeRate)
+
+	for _, rate := range rates {
+		byCurrency[rate.Currency] = append(byCurrency[rate.Currency], rate)
+	}
+
+	for _, currencyRates := range byCurrency {
+		sort.Slice(currencyRates, func(i, j int) bool {
+			return currencyRates[i].Month.Time().Before(currencyRates[j].Month.Time())
+		})
+	}
+
+	return &ExchangeRates{byCurrency: byCurrency}
+}
+
+// Rate returns the price of one unit of currency in DefaultCurrency valid in the month
+func (r *ExchangeRates) Rate(currency string, month MonthYear) (float64, error) {
+	if currency == DefaultCurrency {
+		return 1, nil
+	}
+
+	currencyRates := r.byCurrency[currency]
+
+	// index of the first rate starting after the month
+	i := sort.Search(len(currencyRates), func(i int) bool {
+		return currencyRates[i].Month.Time().After(month.Time())
+	})
+
+	if i == 0 {
+		return 0, &NoExchangeRateError{Currency: currency, Month: month}
+	}
+
+	return currencyRates[i-1].Rate, nil
+}
+
+// Convert converts amount from one currency to another with rates valid in the month
+func (r *ExchangeRates) Convert(amount float64, from, to string, month MonthYear) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	fromRate, err := r.Rate(from, month)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := r.Rate(to, month)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * fromRate / toRate, nil
+}
+
+// CalculateAmount sets amount of the subscription cost in currency.
+// Each billed month is charged with the price effective in it and converted with the rate valid in it.
+func (c *SubscriptionCost) CalculateAmount(rates *ExchangeRates, currency string) error {
+	if c.Currency == currency {
+		var prices int
+		for i := 0; i < c.Months; i++ {
+			prices += c.PriceAt(c.BilledFrom.AddMonths(i))
+		}
+
+		c.Amount = ProratedCost(prices, c.BillingPeriod, c.BillingInterval, 1)
+		return nil
+	}
+
+	var total float64
+
+	for i := 0; i < c.Months; i++ {
+		month := c.BilledFrom.AddMonths(i)
+
+		monthly := proratedCost(c.PriceAt(month), c.BillingPeriod, c.BillingInterval, 1)
+
+		amount, err := rates.Convert(monthly, c.Currency, currency, month)
+		if err != nil {
+			return err
+		}
+		total += amount
+	}
+
+	c.Amount = int(math.Round(total))
+
+	return nil
+}
+
+// PriceAt returns subscription price effective in the month
+func (c *SubscriptionCost) PriceAt(month MonthYear) int {
+	if len(c.Prices) == 0 {
+		return c.Price
+	}
+
+	return c.Prices.PriceAt(month)
+}
+
+// NeedsConversion reports whether any of the costs is in currency other than the given one
+func NeedsConversion(costs []SubscriptionCost, currency string) bool {
+	for _, c := range costs {
+		if c.Currency != currency {
+			return true
+		}
+	}
+	return false
+}
+
+// SumCosts calculates amounts of the costs in currency and their total
+func SumCosts(costs []SubscriptionCost, currency string, rates *ExchangeRates) (*SubscriptionsSum, error) {
+	sum := SubscriptionsSum{Currency: currency, Subscriptions: costs}
+
+	for i := range sum.Subscriptions {
+		cost := &sum.Subscriptions[i]
+
+		if err := cost.CalculateAmount(rates, currency); err != nil {
+			return nil, err
+		}
+
+		sum.Amount += cost.Amount
+	}
+
+	return &sum, nil
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubscriptionCostCalculateAmount(t *testing.T) {
+	rates := NewExchangeRates([]ExchangeRate{
+		{Currency: "USD", Month: month(t, "01-2025"), Rate: 90},
+		{Currency: "USD", Month: month(t, "03-2025"), Rate: 100},
+	})
+
+	for _, tc := range []struct {
+		name     string
+		cost     SubscriptionCost
+		currency string
+		want     int
+	}{
+		{
+			name: "single price",
+			cost: SubscriptionCost{
+				Price: 499, Currency: "RUB", BillingPeriod: BillingPeriodMonthly,
+				BilledFrom: month(t, "01-2025"), Months: 3,
+			},
+			currency: "RUB",
+			want:     1497,
+		},
+		{
+			name: "segments split at month boundary",
+			cost: SubscriptionCost{
+				Price: 200, Currency: "RUB", BillingPeriod: BillingPeriodMonthly,
+				BilledFrom: month(t, "02-2025"), Months: 4,
+				Prices: schedule(t, 100, "01-2025", 200, "04-2025"),
+			},
+			currency: "RUB",
+			want:     100 + 100 + 200 + 200,
+		},
+		{
+			name: "segment after the billed period is not used",
+			cost: SubscriptionCost{
+				Price: 200, Currency: "RUB", BillingPeriod: BillingPeriodMonthly,
+				BilledFrom: month(t, "01-2025"), Months: 2,
+				Prices: schedule(t, 100, "01-2025", 200, "04-2025"),
+			},
+			currency: "RUB",
+			want:     200,
+		},
+		{
+			name: "yearly segments are rounded once",
+			cost: SubscriptionCost{
+				Price: 1200, Currency: "RUB", BillingPeriod: BillingPeriodYearly,
+				BilledFrom: month(t, "01-2025"), Months: 2,
+				Prices: schedule(t, 6, "01-2025", 1200, "02-2025"),
+			},
+			currency: "RUB",
+			// 0.5 of the first month and 100 of the second one are summed before rounding
+			want: 101,
+		},
+		{
+			name: "weekly price converted to months",
+			cost: SubscriptionCost{
+				Price: 120, Currency: "RUB", BillingPeriod: BillingPeriodWeekly,
+				BilledFrom: month(t, "01-2025"), Months: 2,
+			},
+			currency: "RUB",
+			want:     1040,
+		},
+		{
+			name: "weekly price converted with rate of every month",
+			cost: SubscriptionCost{
+				Price: 12, Currency: "USD", BillingPeriod: BillingPeriodWeekly,
+				BilledFrom: month(t, "02-2025"), Months: 2,
+			},
+			currency: "RUB",
+			// 52 USD per month at 90 and then at 100
+			want: 52*90 + 52*100,
+		},
+		{
+			name: "segments converted with rate of every month",
+			cost: SubscriptionCost{
+				Price: 20, Currency: "USD", BillingPeriod: BillingPeriodMonthly,
+				BilledFrom: month(t, "02-2025"), Months: 2,
+				Prices: schedule(t, 10, "01-2025", 20, "03-2025"),
+			},
+			currency: "RUB",
+			want:     10*90 + 20*100,
+		},
+		{
+			name: "converted to another currency",
+			cost: SubscriptionCost{
+				Price: 1000, Currency: "RUB", BillingPeriod: BillingPeriodMonthly,
+				BilledFrom: month(t, "03-2025"), Months: 1,
+			},
+			currency: "USD",
+			want:     10,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cost := tc.cost
+
+			if err := cost.CalculateAmount(rates, tc.currency); err != nil {
+				t.Fatalf("calculate amount: %v", err)
+			}
+
+			if cost.Amount != tc.want {
+				t.Errorf("got amount %d, want %d", cost.Amount, tc.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionCostCalculateAmountNoRate(t *testing.T) {
+	rates := NewExchangeRates([]ExchangeRate{{Currency: "USD", Month: month(t, "03-2025"), Rate: 100}})
+
+	cost := SubscriptionCost{
+		Price: 10, Currency: "USD", BillingPeriod: BillingPeriodMonthly,
+		BilledFrom: month(t, "02-2025"), Months: 2,
+	}
+
+	err := cost.CalculateAmount(rates, DefaultCurrency)
+
+	var noRateErr *NoExchangeRateError
+	if !errors.As(err, &noRateErr) || !errors.Is(err, ErrNoExchangeRate) {
+		t.Fatalf("got error %v, want no exchange rate error", err)
+	}
+
+	if noRateErr.Currency != "USD" || !noRateErr.Month.Time().Equal(month(t, "02-2025").Time()) {
+		t.Errorf("got missing rate of %s in %s, want USD in 02-2025",
+			noRateErr.Currency, noRateErr.Month.Time().Format(MonthYearLayout))
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionDelete  = "delete"
+	HistoryActionRestore = "restore"
+	HistoryActionPurge   = "purge"
+)
+
+// SubscriptionHistoryRecord is an immutable record of a subscription change
+type SubscriptionHistoryRecord struct {
+	ID             int64     `json:"id" db:"id" example:"1"`
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Action         string    `json:"action" db:"action" example:"update" enums:"create,update,delete,restore,purge"`
+
+	// OldValue and NewValue are subscription states before and after the change, null for create and purge respectively
+	OldValue json.RawMessage `json:"old_value" db:"old_value" swaggertype:"object"`
+	NewValue json.RawMessage `json:"new_value" db:"new_value" swaggertype:"object"`
+
+	Actor     *string   `json:"actor" db:"actor" example:"admin"`
+	RequestID *string   `json:"request_id" db:"request_id" example:"e9471daf-ddc7-4993-8ada-788870d7506d"`
+	CreatedAt time.Time `json:"created_at" db:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// MarshalHistoryValue encodes subscription state stored in history, nil is stored as null
+func MarshalHistoryValue(sub *Subscription) (json.RawMessage, error) {
+	if sub == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(sub)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyRecord is a stored result of request made with Idempotency-Key header.
+// Keys are scoped by Subject of the client identity, empty if authentication is disabled.
+type IdempotencyRecord struct {
+	Subject     string `db:"subject"`
+	Key         string `db:"key"`
+	RequestHash string `db:"request_hash"`
+	// StatusCode is 0 while the first request with the key is in progress
+	StatusCode int `db:"status_code"`
+	// ResponseHeaders are replayed headers of the response encoded as JSON object
+	ResponseHeaders json.RawMessage `db:"response_headers"`
+	ResponseBody    []byte          `db:"response_body"`
+	CreatedAt       time.Time       `db:"created_at"`
+	ExpiresAt       time.Time       `db:"expires_at"`
+}
+
+// InProgress reports whether response of the first request with the key is not stored yet
+func (r *IdempotencyRecord) InProgress() bool {
+	return r.StatusCode == 0
+}
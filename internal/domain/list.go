@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sort fields of subscriptions list
+const (
+	SortByCreatedAt   = "created_at"
+	SortByStartDate   = "start_date"
+	SortByPrice       = "price"
+	SortByServiceName = "service_name"
+)
+
+// Sort orders of subscriptions list
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+const (
+	createdAtCursorLayout = "2006-01-02T15:04:05.999999"
+	startDateCursorLayout = "2006-01-02"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListSubscriptionsFilter list filter, sorting and pagination parameters.
+// Nil fields are not applied.
+type ListSubscriptionsFilter struct {
+	UserID            *uuid.UUID
+	ServiceName       *string
+	ServiceNamePrefix *string
+	MinPrice          *int
+	MaxPrice          *int
+	// ActiveIn selects subscriptions active in the given month
+	ActiveIn *MonthYear
+	// IncludeDeleted adds soft deleted subscriptions to the list
+	IncludeDeleted bool
+
+	Sort   string
+	Order  string
+	Limit  int
+	Cursor *ListCursor
+}
+
+// SubscriptionsPage represents a page of subscriptions list
+type SubscriptionsPage struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCJ9"`
+}
+
+// ListCursor points to the last item of a subscriptions list page.
+// Value is the sort field value of the item, ID breaks ties between equal values.
+// Sort and Order of the page are kept, so that the cursor cannot continue a differently sorted list.
+type ListCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// NewListCursor creates cursor pointing to the subscription in list sorted by sort field in order
+func NewListCursor(sub *Subscription, sort, order string) ListCursor {
+	cursor := ListCursor{Sort: sort, Order: order, ID: sub.ID}
+
+	switch sort {
+	case SortByStartDate:
+		cursor.Value = sub.StartDate.Time().Format(startDateCursorLayout)
+	case SortByPrice:
+		cursor.Value = strconv.Itoa(sub.Price)
+	case SortByServiceName:
+		cursor.Value = sub.ServiceName
+	default:
+		cursor.Value = sub.CreatedAt.Format(createdAtCursorLayout)
+	}
+
+	return cursor
+}
+
+// Encode encodes cursor to opaque string passed to clients
+func (c ListCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeListCursor decodes cursor previously encoded with Encode
+func DecodeListCursor(s string) (*ListCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor ListCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if !IsValidSortField(cursor.Sort) || (cursor.Order != OrderAsc && cursor.Order != OrderDesc) {
+		return nil, ErrInvalidCursor
+	}
+
+	if _, err := cursor.key(); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// IsValidSortField reports whether subscriptions list can be sorted by the field
+func IsValidSortField(field string) bool {
+	switch field {
+	case SortByCreatedAt, SortByStartDate, SortByPrice, SortByServiceName:
+		return true
+	}
+	return false
+}
+
+// CompareSubscriptions compares subscriptions by sort field, ties are broken by ID
+func CompareSubscriptions(a, b *Subscription, sort string) int {
+	var c int
+
+	switch sort {
+	case SortByStartDate:
+		c = a.StartDate.Time().Compare(b.StartDate.Time())
+	case SortByPrice:
+		c = cmp.Compare(a.Price, b.Price)
+	case SortByServiceName:
+		c = strings.Compare(a.ServiceName, b.ServiceName)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	if c != 0 {
+		return c
+	}
+
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// Compare compares subscription with the item cursor points to, in the order of cursor sort field
+func (c ListCursor) Compare(sub *Subscription) (int, error) {
+	key, err := c.key()
+	if err != nil {
+		return 0, err
+	}
+
+	return CompareSubscriptions(sub, key, c.Sort), nil
+}
+
+// key returns subscription with the sort field value and ID of the item cursor points to.
+// Value is parsed by the rules of the sort field, so that it can be safely cast in queries.
+func (c ListCursor) key() (*Subscription, error) {
+	key := Subscription{ID: c.ID}
+
+	switch c.Sort {
+	case SortByStartDate:
+		t, err := time.Parse(startDateCursorLayout, c.Value)
+		if err != nil || t.Year() < 1 {
+			return nil, ErrInvalidCursor
+		}
+		key.StartDate = MonthYear(t)
+	case SortByPrice:
+		// price column is a 32-bit integer
+		price, err := strconv.ParseInt(c.Value, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		key.Price = int(price)
+	case SortByServiceName:
+		key.ServiceName = c.Value
+	default:
+		t, err := time.Parse(createdAtCursorLayout, c.Value)
+		if err != nil || t.Year() < 1 {
+			return nil, ErrInvalidCursor
+		}
+		key.CreatedAt = t
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "sort"
+
+// PriceSegment is subscription price effective from the month until the next segment
+type PriceSegment struct {
+	Price         int       `json:"price" db:"price" example:"499"`
+	EffectiveFrom MonthYear `json:"effective_from" db:"effective_from" example:"07-2025"`
+}
+
+// PriceSchedule is a list of price segments sorted by effective month
+type PriceSchedule []PriceSegment
+
+// PriceAt returns price effective in the month, the first price is used before the first segment
+func (s PriceSchedule) PriceAt(month MonthYear) int {
+	// index of the first segment starting after the month
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].EffectiveFrom.Time().After(month.Time())
+	})
+
+	if i == 0 {
+		return s[0].Price
+	}
+
+	return s[i-1].Price
+}
+
+// ChangePrice returns schedule with price effective from the month, segments starting later are replaced.
+// Price effective from the subscription start or earlier replaces the whole schedule.
+func (s PriceSchedule) ChangePrice(price int, from, start MonthYear) PriceSchedule {
+	if !from.Time().After(start.Time()) {
+		return PriceSchedule{{Price: price, EffectiveFrom: start}}
+	}
+
+	schedule := make(PriceSchedule, 0, len(s)+1)
+
+	for _, segment := range s {
+		if segment.EffectiveFrom.Time().Before(from.Time()) {
+			schedule = append(schedule, segment)
+		}
+	}
+
+	return append(schedule, PriceSegment{Price: price, EffectiveFrom: from})
+}
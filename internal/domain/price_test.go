@@ -0,0 +1,123 @@
+package domain
+
+import "testing"
+
+func month(t *testing.T, s string) MonthYear {
+	t.Helper()
+
+	my, err := ParseMonthYear(s)
+	if err != nil {
+		t.Fatalf("parse month %q: %v", s, err)
+	}
+
+	return my
+}
+
+// schedule builds price schedule from pairs of price and effective month
+func schedule(t *testing.T, segments ...any) PriceSchedule {
+	t.Helper()
+
+	s := make(PriceSchedule, 0, len(segments)/2)
+	for i := 0; i < len(segments); i += 2 {
+		s = append(s, PriceSegment{Price: segments[i].(int), EffectiveFrom: month(t, segments[i+1].(string))})
+	}
+
+	return s
+}
+
+func assertSchedule(t *testing.T, got, want PriceSchedule) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d segments %+v, want %d %+v", len(got), got, len(want), want)
+	}
+
+	for i := range want {
+		if got[i].Price != want[i].Price || !got[i].EffectiveFrom.Time().Equal(want[i].EffectiveFrom.Time()) {
+			t.Errorf("segment %d: got %d from %s, want %d from %s", i,
+				got[i].Price, got[i].EffectiveFrom.Time().Format(MonthYearLayout),
+				want[i].Price, want[i].EffectiveFrom.Time().Format(MonthYearLayout))
+		}
+	}
+}
+
+func TestPriceScheduleChangePrice(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		schedule PriceSchedule
+		price    int
+		from     string
+		start    string
+		want     PriceSchedule
+	}{
+		{
+			name:     "from start replaces schedule",
+			schedule: schedule(t, 100, "01-2025", 200, "04-2025"),
+			price:    300, from: "01-2025", start: "01-2025",
+			want: schedule(t, 300, "01-2025"),
+		},
+		{
+			name:     "before start replaces schedule from start",
+			schedule: schedule(t, 100, "03-2025"),
+			price:    300, from: "01-2025", start: "03-2025",
+			want: schedule(t, 300, "03-2025"),
+		},
+		{
+			name:     "after last segment appends segment",
+			schedule: schedule(t, 100, "01-2025"),
+			price:    200, from: "04-2025", start: "01-2025",
+			want: schedule(t, 100, "01-2025", 200, "04-2025"),
+		},
+		{
+			name:     "later segments are replaced",
+			schedule: schedule(t, 100, "01-2025", 200, "04-2025", 300, "07-2025"),
+			price:    150, from: "03-2025", start: "01-2025",
+			want: schedule(t, 100, "01-2025", 150, "03-2025"),
+		},
+		{
+			name:     "segment of the same month is replaced",
+			schedule: schedule(t, 100, "01-2025", 200, "04-2025"),
+			price:    250, from: "04-2025", start: "01-2025",
+			want: schedule(t, 100, "01-2025", 250, "04-2025"),
+		},
+		{
+			name:     "empty schedule",
+			schedule: nil,
+			price:    200, from: "04-2025", start: "01-2025",
+			want: schedule(t, 200, "04-2025"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			original := append(PriceSchedule(nil), tc.schedule...)
+
+			got := tc.schedule.ChangePrice(tc.price, month(t, tc.from), month(t, tc.start))
+
+			assertSchedule(t, got, tc.want)
+			assertSchedule(t, tc.schedule, original)
+		})
+	}
+}
+
+func TestPriceScheduleSegmentBoundaries(t *testing.T) {
+	s := schedule(t, 100, "02-2025", 200, "04-2025", 300, "01-2026")
+
+	// a segment is effective from the first day of its month until the month of the next segment
+	for _, tc := range []struct {
+		month string
+		want  int
+	}{
+		{"01-2025", 100},
+		{"02-2025", 100},
+		{"03-2025", 100},
+		{"04-2025", 200},
+		{"12-2025", 200},
+		{"01-2026", 300},
+		{"06-2030", 300},
+	} {
+		t.Run(tc.month, func(t *testing.T) {
+			if got := s.PriceAt(month(t, tc.month)); got != tc.want {
+				t.Errorf("got price %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
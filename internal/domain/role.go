@@ -0,0 +1,29 @@
+package domain
+
+// Role defines operations available to the client, every role includes permissions of the previous one
+type Role string
+
+const (
+	// RoleViewer may read subscriptions of all users, e.g. support staff
+	RoleViewer Role = "viewer"
+	// RoleEditor may also create, change and soft delete subscriptions, e.g. billing engineers
+	RoleEditor Role = "editor"
+	// RoleAdmin may also hard delete subscriptions and manage API keys
+	RoleAdmin Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether the role has permissions of the required role
+func (r Role) Includes(required Role) bool {
+	return r.IsValid() && roleRanks[r] >= roleRanks[required]
+}
@@ -12,14 +12,26 @@ import (
 type Subscription struct {
 	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	ServiceName string    `json:"service_name" db:"service_name" example:"Netflix"`
-	Price       int       `json:"price" db:"price" example:"499"`
+	// Price is the latest price of subscription, earlier prices are used for months they were effective in
+	Price int `json:"price" db:"price" example:"499"`
+
+	Currency string `json:"currency" db:"currency" example:"RUB"`
+
+	BillingPeriod   BillingPeriod `json:"billing_period" db:"billing_period" example:"monthly"`
+	BillingInterval int           `json:"billing_interval" db:"billing_interval" example:"1"`
 
 	UserID    uuid.UUID  `json:"user_id" db:"user_id" example:"111e8400-e29b-41d4-a716-446655440000"`
 	StartDate MonthYear  `json:"start_date" db:"start_date" example:"07-2025"`
 	EndDate   *MonthYear `json:"end_date" db:"end_date" example:"12-2025"`
 
+	// Version is incremented on every update and used as ETag
+	Version int `json:"version" db:"version" example:"1"`
+
 	CreatedAt time.Time `json:"created_at" db:"created_at" example:"2025-01-01T12:00:00Z"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at" example:"2025-01-01T12:00:00Z"`
+
+	// DeletedAt is set when subscription is soft deleted, such subscriptions are purged after retention period
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at" example:"2025-02-01T12:00:00Z"`
 }
 
 // CreateSubscriptionInput input payload
@@ -30,6 +42,15 @@ type CreateSubscriptionInput struct {
 	// @Schema(required=true)
 	Price int `json:"price" example:"499"`
 
+	// Currency is ISO 4217 code of price currency, RUB by default
+	Currency string `json:"currency,omitempty" example:"RUB"`
+
+	// BillingPeriod is the period price is charged for, monthly by default
+	BillingPeriod BillingPeriod `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"`
+
+	// BillingInterval is the number of billing periods price is charged for, 1 by default
+	BillingInterval int `json:"billing_interval,omitempty" example:"1"`
+
 	// @Schema(required=true)
 	UserID uuid.UUID `json:"user_id" example:"111e8400-e29b-41d4-a716-446655440000"`
 
@@ -39,39 +60,172 @@ type CreateSubscriptionInput struct {
 	EndDate *MonthYear `json:"end_date,omitempty" example:"12-2025"`
 }
 
+// SetDefaults sets default values of omitted optional fields
+func (in *CreateSubscriptionInput) SetDefaults() {
+	if in.BillingPeriod == "" {
+		in.BillingPeriod = BillingPeriodMonthly
+	}
+
+	if in.Currency == "" {
+		in.Currency = DefaultCurrency
+	}
+
+	if in.BillingInterval == 0 {
+		in.BillingInterval = DefaultBillingInterval
+	}
+}
+
 // UpdateSubscriptionInput update payload
 type UpdateSubscriptionInput struct {
-	ServiceName *string     `json:"service_name,omitempty" example:"Spotify"`
-	Price       *int        `json:"price,omitempty" example:"299"`
-	StartDate   *MonthYear  `json:"start_date,omitempty" example:"08-2025"`
-	EndDate     **MonthYear `json:"end_date,omitempty" example:"11-2025"`
+	ServiceName *string `json:"service_name,omitempty" example:"Spotify"`
+	Price       *int    `json:"price,omitempty" example:"299"`
+	Currency    *string `json:"currency,omitempty" example:"USD"`
+
+	BillingPeriod   *BillingPeriod `json:"billing_period,omitempty" example:"yearly" enums:"weekly,monthly,quarterly,yearly"`
+	BillingInterval *int           `json:"billing_interval,omitempty" example:"1"`
+
+	StartDate *MonthYear  `json:"start_date,omitempty" example:"08-2025"`
+	EndDate   **MonthYear `json:"end_date,omitempty" example:"11-2025"`
+
+	// PriceEffectiveFrom applies price from the month keeping earlier prices, by default price is changed retroactively.
+	// Without it price equal to the current one does not change price history.
+	PriceEffectiveFrom *MonthYear `json:"price_effective_from,omitempty" example:"10-2025"`
+
+	// ExpectedVersion makes update fail with version mismatch if subscription has another version
+	ExpectedVersion *int `json:"-"`
+}
+
+// ChangesPrice reports whether update changes price schedule of the subscription.
+// Price equal to the current one without PriceEffectiveFrom keeps the schedule, so that updates repeating
+// the whole subscription, e.g. rows of CSV import, do not flatten earlier prices.
+func (in UpdateSubscriptionInput) ChangesPrice(old *Subscription) bool {
+	return in.Price != nil && (in.PriceEffectiveFrom != nil || *in.Price != old.Price)
+}
+
+// ChangePrice returns price schedule of the subscription after the update, ok is false if schedule is not changed.
+// Subscription must be already updated with Apply, old is the subscription before the update.
+func (in UpdateSubscriptionInput) ChangePrice(schedule PriceSchedule, old, sub *Subscription) (_ PriceSchedule, ok bool) {
+	if !in.ChangesPrice(old) {
+		return schedule, false
+	}
+
+	from := sub.StartDate
+	if in.PriceEffectiveFrom != nil {
+		from = *in.PriceEffectiveFrom
+	}
+
+	return schedule.ChangePrice(*in.Price, from, sub.StartDate), true
 }
 
-// SumSubscriptionsFilter sum filter
+// Apply sets fields present in update input to the subscription
+func (in UpdateSubscriptionInput) Apply(sub *Subscription) {
+	if in.ServiceName != nil {
+		sub.ServiceName = *in.ServiceName
+	}
+
+	if in.Price != nil {
+		sub.Price = *in.Price
+	}
+
+	if in.Currency != nil {
+		sub.Currency = *in.Currency
+	}
+
+	if in.BillingPeriod != nil {
+		sub.BillingPeriod = *in.BillingPeriod
+	}
+
+	if in.BillingInterval != nil {
+		sub.BillingInterval = *in.BillingInterval
+	}
+
+	if in.StartDate != nil {
+		sub.StartDate = *in.StartDate
+	}
+
+	if in.EndDate != nil {
+		sub.EndDate = *in.EndDate
+	}
+}
+
+// SumSubscriptionsFilter selects subscriptions and period of the sum, every filter is optional
 type SumSubscriptionsFilter struct {
-	// @Schema(required=true)
-	ServiceName string `json:"service_name" example:"Netflix"`
+	// UserIDs and ServiceNames select subscriptions of any of the users and services, all if empty
+	UserIDs      []uuid.UUID
+	ServiceNames []string
 
-	// @Schema(required=true)
-	UserID uuid.UUID `json:"user_id" example:"111e8400-e29b-41d4-a716-446655440000"`
+	// From is the first month of the period, subscriptions are summed from their start if nil
+	From *MonthYear
+	// To is the last month of the period, the current month by default
+	To *MonthYear
 
-	// @Schema(required=true)
-	From MonthYear `json:"from" example:"01-2025"`
+	// Currency is ISO 4217 code of the currency to convert prices to, RUB by default
+	Currency string
+}
 
-	// @Schema(required=true)
-	To MonthYear `json:"to" example:"12-2025"`
+// SetDefaults sets default values of omitted optional fields
+func (in *SumSubscriptionsFilter) SetDefaults() {
+	if in.To == nil {
+		to := CurrentMonth()
+		in.To = &to
+	}
+
+	if in.Currency == "" {
+		in.Currency = DefaultCurrency
+	}
+}
+
+// SubscriptionCost represents cost of a single subscription within the sum period
+type SubscriptionCost struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName    string    `json:"service_name" db:"service_name" example:"Netflix"`
+	Price          int       `json:"price" db:"price" example:"499"`
+	Currency       string    `json:"currency" db:"currency" example:"USD"`
+
+	BillingPeriod   BillingPeriod `json:"billing_period" db:"billing_period" example:"monthly"`
+	BillingInterval int           `json:"billing_interval" db:"billing_interval" example:"1"`
+
+	// BilledFrom and BilledTo are subscription period clamped to the sum period
+	BilledFrom MonthYear `json:"billed_from" db:"billed_from" example:"07-2025"`
+	BilledTo   MonthYear `json:"billed_to" db:"billed_to" example:"12-2025"`
+
+	// Prices are price segments of subscription, Price is used for all months if empty
+	Prices PriceSchedule `json:"prices,omitempty" db:"-"`
+
+	Months int `json:"months" db:"months" example:"6"`
+	// Amount is the cost in the sum currency
+	Amount int `json:"amount" db:"-" example:"2994"`
 }
 
+// SubscriptionsSum represents total cost of subscriptions within the sum period
+type SubscriptionsSum struct {
+	Currency      string             `json:"currency" example:"RUB"`
+	Amount        int                `json:"amount" example:"2994"`
+	Subscriptions []SubscriptionCost `json:"subscriptions"`
+}
+
+// MonthYearLayout is the layout of month-year dates used in API
+const MonthYearLayout = "01-2006"
+
 // MonthYear represents month-year date (MM-YYYY)
 type MonthYear time.Time
 
+// ParseMonthYear parses month-year date in MM-YYYY format
+func ParseMonthYear(s string) (MonthYear, error) {
+	t, err := time.Parse(MonthYearLayout, s)
+	if err != nil {
+		return MonthYear{}, err
+	}
+	return MonthYear(t), nil
+}
+
 func (my *MonthYear) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), `"`)
-	t, err := time.Parse("01-2006", s)
+	t, err := ParseMonthYear(s)
 	if err != nil {
 		return err
 	}
-	*my = MonthYear(t)
+	*my = t
 	return nil
 }
 
@@ -88,3 +242,31 @@ func (my *MonthYear) MonthYearPtrToTimePtr() *time.Time {
 	t := time.Time(*my)
 	return &t
 }
+
+func (my MonthYear) Time() time.Time {
+	return time.Time(my)
+}
+
+// CurrentMonth returns the current month in UTC
+func CurrentMonth() MonthYear {
+	now := time.Now().UTC()
+	return MonthYear(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// AddMonths returns month-year date months after the date
+func (my MonthYear) AddMonths(months int) MonthYear {
+	return MonthYear(my.Time().AddDate(0, months, 0))
+}
+
+// MonthsBetween returns number of months from one month to another, both inclusive.
+// Returns 0 if from is after to.
+func MonthsBetween(from, to MonthYear) int {
+	f, t := from.Time(), to.Time()
+
+	months := (t.Year()-f.Year())*12 + int(t.Month()) - int(f.Month()) + 1
+	if months < 0 {
+		return 0
+	}
+
+	return months
+}
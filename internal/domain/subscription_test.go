@@ -0,0 +1,80 @@
+package domain
+
+import "testing"
+
+func TestUpdateSubscriptionInputChangePrice(t *testing.T) {
+	current := schedule(t, 100, "01-2025", 200, "04-2025")
+
+	intPtr := func(v int) *int { return &v }
+	monthPtr := func(s string) *MonthYear {
+		my := month(t, s)
+		return &my
+	}
+
+	for _, tc := range []struct {
+		name      string
+		in        UpdateSubscriptionInput
+		startDate string
+		wantOK    bool
+		want      PriceSchedule
+	}{
+		{
+			name:      "without price",
+			in:        UpdateSubscriptionInput{},
+			startDate: "01-2025",
+			want:      current,
+		},
+		{
+			name:      "same price keeps schedule",
+			in:        UpdateSubscriptionInput{Price: intPtr(200)},
+			startDate: "01-2025",
+			want:      current,
+		},
+		{
+			name:      "same price from month changes schedule",
+			in:        UpdateSubscriptionInput{Price: intPtr(200), PriceEffectiveFrom: monthPtr("02-2025")},
+			startDate: "01-2025",
+			wantOK:    true,
+			want:      schedule(t, 100, "01-2025", 200, "02-2025"),
+		},
+		{
+			name:      "another price is retroactive",
+			in:        UpdateSubscriptionInput{Price: intPtr(300)},
+			startDate: "01-2025",
+			wantOK:    true,
+			want:      schedule(t, 300, "01-2025"),
+		},
+		{
+			name:      "another price from month keeps earlier segments",
+			in:        UpdateSubscriptionInput{Price: intPtr(300), PriceEffectiveFrom: monthPtr("06-2025")},
+			startDate: "01-2025",
+			wantOK:    true,
+			want:      schedule(t, 100, "01-2025", 200, "04-2025", 300, "06-2025"),
+		},
+		{
+			name:      "retroactive price starts from the new start date",
+			in:        UpdateSubscriptionInput{Price: intPtr(300), StartDate: monthPtr("03-2025")},
+			startDate: "01-2025",
+			wantOK:    true,
+			want:      schedule(t, 300, "03-2025"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			old := Subscription{Price: 200, StartDate: month(t, tc.startDate)}
+
+			sub := old
+			tc.in.Apply(&sub)
+
+			if got := tc.in.ChangesPrice(&old); got != tc.wantOK {
+				t.Errorf("ChangesPrice() = %t, want %t", got, tc.wantOK)
+			}
+
+			got, ok := tc.in.ChangePrice(current, &old, &sub)
+			if ok != tc.wantOK {
+				t.Errorf("ChangePrice() ok = %t, want %t", ok, tc.wantOK)
+			}
+
+			assertSchedule(t, got, tc.want)
+		})
+	}
+}
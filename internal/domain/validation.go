@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Validation error codes
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeMin      = "min"
+	CodeRange    = "range"
+)
+
+// FieldError describes a single invalid field of input
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Code    string `json:"code" example:"min"`
+	Message string `json:"message" example:"price must not be negative"`
+}
+
+// ValidationErrors is a list of all violations found in input
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationErrors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Validate returns all violations of create input, nil if input is valid.
+// Defaults must be set before validation.
+func (in CreateSubscriptionInput) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	if strings.TrimSpace(in.ServiceName) == "" {
+		errs.Add("service_name", CodeRequired, "service name is required")
+	}
+
+	if in.Price < 0 {
+		errs.Add("price", CodeMin, "price must not be negative")
+	}
+
+	if !IsValidCurrency(in.Currency) {
+		errs.Add("currency", CodeInvalid, "currency must be ISO 4217 code")
+	}
+
+	if !in.BillingPeriod.IsValid() {
+		errs.Add("billing_period", CodeInvalid, "billing period must be one of weekly, monthly, quarterly, yearly")
+	}
+
+	if in.BillingInterval < 1 {
+		errs.Add("billing_interval", CodeMin, "billing interval must be positive")
+	}
+
+	if in.UserID == uuid.Nil {
+		errs.Add("user_id", CodeRequired, "user id is required")
+	}
+
+	if in.StartDate.Time().IsZero() {
+		errs.Add("start_date", CodeRequired, "start date is required")
+	}
+
+	if in.EndDate != nil && !in.StartDate.Time().IsZero() && in.EndDate.Time().Before(in.StartDate.Time()) {
+		errs.Add("end_date", CodeRange, "end date must not be before start date")
+	}
+
+	return errs
+}
+
+// Validate returns all violations of update input, nil if input is valid
+func (in UpdateSubscriptionInput) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	if in.ServiceName != nil && strings.TrimSpace(*in.ServiceName) == "" {
+		errs.Add("service_name", CodeRequired, "service name must not be empty")
+	}
+
+	if in.Price != nil && *in.Price < 0 {
+		errs.Add("price", CodeMin, "price must not be negative")
+	}
+
+	if in.Currency != nil && !IsValidCurrency(*in.Currency) {
+		errs.Add("currency", CodeInvalid, "currency must be ISO 4217 code")
+	}
+
+	if in.BillingPeriod != nil && !in.BillingPeriod.IsValid() {
+		errs.Add("billing_period", CodeInvalid, "billing period must be one of weekly, monthly, quarterly, yearly")
+	}
+
+	if in.BillingInterval != nil && *in.BillingInterval < 1 {
+		errs.Add("billing_interval", CodeMin, "billing interval must be positive")
+	}
+
+	if in.PriceEffectiveFrom != nil && in.Price == nil {
+		errs.Add("price", CodeRequired, "price is required when price effective from is set")
+	}
+
+	if in.StartDate != nil && in.EndDate != nil && *in.EndDate != nil && (*in.EndDate).Time().Before(in.StartDate.Time()) {
+		errs.Add("end_date", CodeRange, "end date must not be before start date")
+	}
+
+	return errs
+}
+
+// ValidatePeriod returns violation if subscription ends before it starts, nil if period is valid.
+// Updated subscription must be checked after merging, since input may contain only one of the dates.
+func (s *Subscription) ValidatePeriod() ValidationErrors {
+	var errs ValidationErrors
+
+	if s.EndDate != nil && s.EndDate.Time().Before(s.StartDate.Time()) {
+		errs.Add("end_date", CodeRange, "end date must not be before start date")
+	}
+
+	return errs
+}
+
+// Validate returns all violations of sum filter, nil if filter is valid.
+// Defaults must be set before validation.
+func (in SumSubscriptionsFilter) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	for _, serviceName := range in.ServiceNames {
+		if strings.TrimSpace(serviceName) == "" {
+			errs.Add("service_name", CodeRequired, "service name must not be empty")
+			break
+		}
+	}
+
+	for _, userID := range in.UserIDs {
+		if userID == uuid.Nil {
+			errs.Add("user_id", CodeRequired, "user id must not be empty")
+			break
+		}
+	}
+
+	if in.From != nil && in.To != nil && in.From.Time().After(in.To.Time()) {
+		errs.Add("to", CodeRange, "to must not be before from")
+	}
+
+	if !IsValidCurrency(in.Currency) {
+		errs.Add("currency", CodeInvalid, "currency must be ISO 4217 code")
+	}
+
+	return errs
+}
+
+// Validate returns all violations of breakdown filter, nil if filter is valid
+func (in BreakdownFilter) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	if in.UserID == uuid.Nil {
+		errs.Add("user_id", CodeRequired, "user id is required")
+	}
+
+	if in.ServiceName != nil && strings.TrimSpace(*in.ServiceName) == "" {
+		errs.Add("service_name", CodeRequired, "service name must not be empty")
+	}
+
+	if in.From.Time().IsZero() {
+		errs.Add("from", CodeRequired, "from is required")
+	}
+
+	if in.To.Time().IsZero() {
+		errs.Add("to", CodeRequired, "to is required")
+	}
+
+	if !in.From.Time().IsZero() && !in.To.Time().IsZero() {
+		if in.From.Time().After(in.To.Time()) {
+			errs.Add("to", CodeRange, "to must not be before from")
+		} else if MonthsBetween(in.From, in.To) > MaxBreakdownMonths {
+			errs.Add("to", CodeRange, fmt.Sprintf("period must not be longer than %d months", MaxBreakdownMonths))
+		}
+	}
+
+	if in.GroupBy != BreakdownGroupByMonth && in.GroupBy != BreakdownGroupByService {
+		errs.Add("group_by", CodeInvalid, "group by must be one of month, service")
+	}
+
+	if !IsValidCurrency(in.Currency) {
+		errs.Add("currency", CodeInvalid, "currency must be ISO 4217 code")
+	}
+
+	return errs
+}
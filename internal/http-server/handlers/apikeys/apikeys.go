@@ -0,0 +1,184 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+func NewAPIKeysHandler(log *slog.Logger, repo handlers.APIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h := NewListHandler(log, repo)
+			h.ServeHTTP(w, r)
+		case http.MethodPost:
+			h := NewCreateHandler(log, repo)
+			h.ServeHTTP(w, r)
+		default:
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+func NewAPIKeyByIDHandler(log *slog.Logger, repo handlers.APIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := strings.TrimPrefix(r.URL.Path, "/api-keys/")
+
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		if r.Method != http.MethodDelete {
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		h := NewRevokeHandler(log, repo, id)
+		h.ServeHTTP(w, r)
+	}
+}
+
+// @Summary List API keys
+// @Description Get all API keys including revoked ones with their last usage time. Tokens are never returned
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /api-keys [get]
+func NewListHandler(log *slog.Logger, repo handlers.APIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.apikeys.NewListHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		keys, err := repo.ListAPIKeys(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "error getting API keys", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, keys)
+	}
+}
+
+// @Summary Create API key
+// @Description Create API key. The token is returned only in this response, only its hash is stored
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body domain.CreateAPIKeyInput true "Create API key"
+// @Success 201 {object} domain.CreatedAPIKey
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /api-keys [post]
+func NewCreateHandler(log *slog.Logger, repo handlers.APIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.apikeys.NewCreateHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		var in domain.CreateAPIKeyInput
+		err := json.NewDecoder(r.Body).Decode(&in)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, "invalid API key input")
+			return
+		}
+
+		in.SetDefaults()
+
+		if errs := in.Validate(); errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid API key input", errs)
+			return
+		}
+
+		token, err := in.GenerateToken()
+		if err != nil {
+			log.ErrorContext(ctx, "error generating API key", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		key, err := repo.CreateAPIKey(ctx, in)
+		if err != nil {
+			log.ErrorContext(ctx, "error creating API key", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusCreated, domain.CreatedAPIKey{APIKey: *key, Token: token})
+	}
+}
+
+// @Summary Revoke API key
+// @Description Revoke API key by ID, requests with the key are rejected immediately
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} lib.SuccessResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /api-keys/{id} [delete]
+func NewRevokeHandler(log *slog.Logger, repo handlers.APIKeyRepository, id uuid.UUID) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.apikeys.NewRevokeHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		err := repo.RevokeAPIKey(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				lib.RespondWithError(w, http.StatusNotFound, "API key not found")
+				return
+			}
+			log.ErrorContext(ctx, "error revoking API key", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, lib.NewSuccessResponse("success"))
+	}
+}
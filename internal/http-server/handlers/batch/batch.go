@@ -0,0 +1,206 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// BatchItemResponse is the result of a single batch operation with HTTP status it would have on its own
+type BatchItemResponse struct {
+	Index        int                  `json:"index" example:"0"`
+	Status       int                  `json:"status" example:"201"`
+	Subscription *domain.Subscription `json:"subscription,omitempty"`
+	Error        *lib.Problem         `json:"error,omitempty"`
+}
+
+// BatchResponse represents results of batch operations in request order
+type BatchResponse struct {
+	Mode    string              `json:"mode" example:"atomic"`
+	Applied bool                `json:"applied" example:"true"`
+	Results []BatchItemResponse `json:"results"`
+}
+
+// @Summary Batch subscriptions operations
+// @Description Execute up to 100 create, update and delete operations in a single transaction.
+// @Description In atomic mode (default) any failed operation rolls back the batch and 422 is returned,
+// @Description in per_item mode successful operations are applied regardless of failed ones
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body domain.BatchRequest true "Batch operations"
+// @Param Idempotency-Key header string false "Unique key of the request to make retries safe"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 413 {object} lib.Problem
+// @Failure 422 {object} BatchResponse
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/batch [post]
+func NewBatchHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.batch.NewBatchHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if r.Method != http.MethodPost {
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		// operations on many subscriptions are not checked per user, they are available to services and staff only
+		if _, ok := middleware.ScopedUserID(ctx); ok {
+			lib.RespondWithError(w, http.StatusForbidden, "batch operations are not available to user tokens")
+			return
+		}
+
+		var in domain.BatchRequest
+		err := json.NewDecoder(r.Body).Decode(&in)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, "invalid batch request")
+			return
+		}
+
+		if in.Mode == "" {
+			in.Mode = domain.BatchModeAtomic
+		}
+
+		var errs domain.ValidationErrors
+
+		if in.Mode != domain.BatchModeAtomic && in.Mode != domain.BatchModePerItem {
+			errs.Add("mode", domain.CodeInvalid, "mode must be one of atomic, per_item")
+		}
+
+		if len(in.Operations) == 0 || len(in.Operations) > domain.MaxBatchSize {
+			errs.Add("operations", domain.CodeRange, fmt.Sprintf("number of operations must be between 1 and %d", domain.MaxBatchSize))
+		}
+
+		if errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid batch request", errs)
+			return
+		}
+
+		atomic := in.Mode == domain.BatchModeAtomic
+
+		resp := BatchResponse{Mode: in.Mode, Results: make([]BatchItemResponse, len(in.Operations))}
+
+		// valid items are executed, indexes map them to operations
+		items := make([]domain.BatchItem, 0, len(in.Operations))
+		indexes := make([]int, 0, len(in.Operations))
+
+		for i, operation := range in.Operations {
+			resp.Results[i].Index = i
+
+			item, errs := operation.ParseItem()
+			if errs != nil {
+				problem := lib.NewProblem(http.StatusBadRequest, "invalid batch operation")
+				problem.Type = lib.ProblemTypeValidation
+				problem.Errors = errs
+				resp.Results[i].setProblem(problem)
+				continue
+			}
+
+			items = append(items, item)
+			indexes = append(indexes, i)
+		}
+
+		if atomic && len(items) < len(in.Operations) {
+			for _, i := range indexes {
+				resp.Results[i].setError(repository.ErrBatchRolledBack)
+			}
+			respond(w, r, resp)
+			return
+		}
+
+		results, err := repo.ExecuteBatch(ctx, items, atomic)
+		if err != nil {
+			log.ErrorContext(ctx, "error executing batch", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		for j, result := range results {
+			item := &resp.Results[indexes[j]]
+
+			if result.Err != nil {
+				item.setError(result.Err)
+				continue
+			}
+
+			item.Status = http.StatusOK
+			if items[j].Op == domain.BatchOpCreate {
+				item.Status = http.StatusCreated
+			}
+			item.Subscription = result.Subscription
+		}
+
+		respond(w, r, resp)
+	}
+}
+
+// respond writes batch response, atomic batch with failed operations is not applied
+func respond(w http.ResponseWriter, r *http.Request, resp BatchResponse) {
+	requestID := reqctx.GetRequestID(r.Context())
+
+	resp.Applied = true
+
+	for i := range resp.Results {
+		if problem := resp.Results[i].Error; problem != nil {
+			problem.RequestID = requestID
+			if resp.Mode == domain.BatchModeAtomic {
+				resp.Applied = false
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if !resp.Applied {
+		status = http.StatusUnprocessableEntity
+	}
+
+	lib.RespondWithJSON(w, status, resp)
+}
+
+func (item *BatchItemResponse) setProblem(problem lib.Problem) {
+	item.Status = problem.Status
+	item.Error = &problem
+}
+
+// setError sets problem of the item failed with repository error
+func (item *BatchItemResponse) setError(err error) {
+	var errs domain.ValidationErrors
+
+	switch {
+	case errors.As(err, &errs):
+		problem := lib.NewProblem(http.StatusBadRequest, "invalid batch operation")
+		problem.Type = lib.ProblemTypeValidation
+		problem.Errors = errs
+		item.setProblem(problem)
+	case errors.Is(err, repository.ErrNotFound):
+		item.setProblem(lib.NewProblem(http.StatusNotFound, "subscription not found"))
+	case errors.Is(err, repository.ErrVersionMismatch):
+		item.setProblem(lib.NewProblem(http.StatusPreconditionFailed, "subscription was modified, version does not match"))
+	case errors.Is(err, repository.ErrBatchRolledBack):
+		item.setProblem(lib.NewProblem(http.StatusFailedDependency, err.Error()))
+	default:
+		item.setProblem(lib.NewProblem(http.StatusInternalServerError, "internal server error"))
+	}
+}
@@ -0,0 +1,137 @@
+package breakdown
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// @Summary Subscriptions cost breakdown
+// @Description Get cost of user subscriptions for every month of the period, optionally split by service.
+// @Description Each month is charged with the price effective in it and converted with the exchange rate valid in it
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param user_id query string false "User ID, defaults to the user of a scoped token"
+// @Param service_name query string false "Exact service name"
+// @Param from query string true "First month of the period (MM-YYYY)"
+// @Param to query string true "Last month of the period (MM-YYYY)"
+// @Param group_by query string false "Bucket grouping" Enums(month, service) default(month)
+// @Param currency query string false "Currency of amounts" default(RUB)
+// @Success 200 {object} domain.Breakdown
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 422 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/breakdown [get]
+func NewBreakdownHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.breakdown.NewBreakdownHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if r.Method != http.MethodGet {
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		// users restricted to their subscriptions get their breakdown when user_id is omitted
+		scopedUserID, _ := middleware.ScopedUserID(ctx)
+
+		filter, errs := parseBreakdownFilter(r.URL.Query(), scopedUserID)
+		if errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid breakdown filter", errs)
+			return
+		}
+
+		if !middleware.AllowsUser(ctx, filter.UserID) {
+			lib.RespondWithError(w, http.StatusForbidden, "subscriptions of another user are not accessible")
+			return
+		}
+
+		breakdown, err := repo.SubscriptionsBreakdown(ctx, filter)
+		if err != nil {
+			var noRateErr *domain.NoExchangeRateError
+			if errors.As(err, &noRateErr) {
+				lib.RespondWithError(w, http.StatusUnprocessableEntity, noRateErr.Error())
+				return
+			}
+			log.ErrorContext(ctx, "error getting subscriptions breakdown", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, breakdown)
+	}
+}
+
+// parseBreakdownFilter parses and validates breakdown filter from query parameters,
+// defaultUserID is used when user_id is omitted
+func parseBreakdownFilter(query url.Values, defaultUserID uuid.UUID) (domain.BreakdownFilter, domain.ValidationErrors) {
+	filter := domain.BreakdownFilter{
+		UserID:   defaultUserID,
+		GroupBy:  domain.BreakdownGroupByMonth,
+		Currency: domain.DefaultCurrency,
+	}
+
+	var errs domain.ValidationErrors
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			errs.Add("user_id", domain.CodeInvalid, "user id must be UUID")
+		}
+		filter.UserID = userID
+	}
+
+	if query.Has("service_name") {
+		v := query.Get("service_name")
+		filter.ServiceName = &v
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("from", domain.CodeInvalid, "from must be in MM-YYYY format")
+		}
+		filter.From = from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("to", domain.CodeInvalid, "to must be in MM-YYYY format")
+		}
+		filter.To = to
+	}
+
+	if v := query.Get("group_by"); v != "" {
+		filter.GroupBy = v
+	}
+
+	if v := query.Get("currency"); v != "" {
+		filter.Currency = v
+	}
+
+	if errs != nil {
+		return filter, errs
+	}
+
+	return filter, filter.Validate()
+}
@@ -2,26 +2,37 @@ package create
 
 import (
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
-	"strings"
 
+	"github.com/google/uuid"
 	"github.com/l-golofastov/subscriptions-manager/internal/domain"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // @Summary Create subscription
-// @Description Create new subscription
+// @Description Create new subscription. Requests with the same Idempotency-Key header and body
+// @Description return the response of the first one instead of creating a duplicate
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param input body domain.CreateSubscriptionInput true "Create subscription"
+// @Param Idempotency-Key header string false "Unique key of the request to make retries safe"
 // @Success 201 {object} domain.Subscription
-// @Failure 400 {object} lib.ErrorResponse
-// @Failure 500 {object} lib.ErrorResponse
+// @Header 201 {string} ETag "Subscription version"
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 409 {object} lib.Problem
+// @Failure 413 {object} lib.Problem
+// @Failure 422 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions [post]
 func NewCreateHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -31,7 +42,7 @@ func NewCreateHandler(log *slog.Logger, repo handlers.SubscriptionRepository) ht
 
 		log = log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
 		var in domain.CreateSubscriptionInput
@@ -41,31 +52,31 @@ func NewCreateHandler(log *slog.Logger, repo handlers.SubscriptionRepository) ht
 			return
 		}
 
-		err = validateCreateInput(in)
-		if err != nil {
-			lib.RespondWithError(w, http.StatusBadRequest, err.Error())
+		// users restricted to their subscriptions may omit their user_id
+		if userID, ok := middleware.ScopedUserID(ctx); ok && in.UserID == uuid.Nil {
+			in.UserID = userID
+		}
+
+		if !middleware.AllowsUser(ctx, in.UserID) {
+			lib.RespondWithError(w, http.StatusForbidden, "subscriptions of another user are not accessible")
+			return
+		}
+
+		in.SetDefaults()
+
+		if errs := in.Validate(); errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid subscription input", errs)
 			return
 		}
 
 		sub, err := repo.CreateSubscription(ctx, in)
 		if err != nil {
-			log.Error("error creating subscription", "error", err)
+			log.ErrorContext(ctx, "error creating subscription", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
+		lib.SetETag(w, sub.Version)
 		lib.RespondWithJSON(w, http.StatusCreated, sub)
 	}
 }
-
-func validateCreateInput(in domain.CreateSubscriptionInput) error {
-	if strings.TrimSpace(in.ServiceName) == "" {
-		return fmt.Errorf("service name is required")
-	}
-
-	if in.Price < 0 {
-		return fmt.Errorf("price must be positive")
-	}
-
-	return nil
-}
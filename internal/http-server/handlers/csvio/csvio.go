@@ -0,0 +1,243 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+// columns of exported CSV, import accepts the same header and ignores columns it does not need
+var columns = []string{
+	"id", "service_name", "price", "currency", "billing_period", "billing_interval",
+	"user_id", "start_date", "end_date", "version", "created_at", "updated_at", "deleted_at",
+}
+
+// requiredColumns must be present in imported CSV header
+var requiredColumns = []string{"service_name", "price", "user_id", "start_date"}
+
+// MaxImportRows limits number of rows in imported CSV
+const MaxImportRows = 10000
+
+// formulaPrefixes are leading characters making spreadsheets evaluate a cell as formula
+const formulaPrefixes = "=+-@\t\r"
+
+// needsEscape reports whether text cell would be evaluated as formula or lose its leading quote in spreadsheets
+func needsEscape(s string) bool {
+	return s != "" && (s[0] == '\'' || strings.ContainsRune(formulaPrefixes, rune(s[0])))
+}
+
+// escapeCell prefixes text cell with a quote, so that spreadsheets show it as text
+func escapeCell(s string) string {
+	if needsEscape(s) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCell removes quote added by escapeCell
+func unescapeCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && needsEscape(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+func formatRow(sub *domain.Subscription) []string {
+	endDate := ""
+	if sub.EndDate != nil {
+		endDate = sub.EndDate.Time().Format(domain.MonthYearLayout)
+	}
+
+	deletedAt := ""
+	if sub.DeletedAt != nil {
+		deletedAt = sub.DeletedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		sub.ID.String(),
+		escapeCell(sub.ServiceName),
+		strconv.Itoa(sub.Price),
+		sub.Currency,
+		string(sub.BillingPeriod),
+		strconv.Itoa(sub.BillingInterval),
+		sub.UserID.String(),
+		sub.StartDate.Time().Format(domain.MonthYearLayout),
+		endDate,
+		strconv.Itoa(sub.Version),
+		sub.CreatedAt.Format(time.RFC3339),
+		sub.UpdatedAt.Format(time.RFC3339),
+		deletedAt,
+	}
+}
+
+// importRow is a parsed CSV row, rows with ID update existing subscriptions
+type importRow struct {
+	id *uuid.UUID
+	in domain.CreateSubscriptionInput
+}
+
+// parseRows reads CSV with header and validates every row with create rules.
+// Returns all row violations, error is returned if CSV itself can not be read.
+func parseRows(r io.Reader) ([]importRow, domain.ValidationErrors, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty CSV")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var errs domain.ValidationErrors
+
+	for _, name := range requiredColumns {
+		if _, ok := index[name]; !ok {
+			errs.Add(name, domain.CodeRequired, fmt.Sprintf("column %s is required", name))
+		}
+	}
+
+	if errs != nil {
+		return nil, errs, nil
+	}
+
+	rows := make([]importRow, 0)
+
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if n > MaxImportRows {
+			return nil, nil, fmt.Errorf("CSV must not contain more than %d rows", MaxImportRows)
+		}
+
+		row, rowErrs := parseRow(record, index)
+
+		for _, fe := range rowErrs {
+			errs.Add(fmt.Sprintf("rows[%d].%s", n, fe.Field), fe.Code, fmt.Sprintf("row %d: %s", n, fe.Message))
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && errs == nil {
+		errs.Add("rows", domain.CodeRequired, "CSV must contain at least one row")
+	}
+
+	return rows, errs, nil
+}
+
+func parseRow(record []string, index map[string]int) (importRow, domain.ValidationErrors) {
+	var row importRow
+
+	var errs domain.ValidationErrors
+
+	value := func(name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	if v := value("id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			errs.Add("id", domain.CodeInvalid, "id must be UUID")
+		}
+		row.id = &id
+	}
+
+	row.in.ServiceName = unescapeCell(value("service_name"))
+	row.in.Currency = value("currency")
+	row.in.BillingPeriod = domain.BillingPeriod(value("billing_period"))
+
+	if v := value("price"); v != "" {
+		price, err := strconv.Atoi(v)
+		if err != nil {
+			errs.Add("price", domain.CodeInvalid, "price must be integer")
+		}
+		row.in.Price = price
+	}
+
+	if v := value("billing_interval"); v != "" {
+		interval, err := strconv.Atoi(v)
+		if err != nil {
+			errs.Add("billing_interval", domain.CodeInvalid, "billing interval must be integer")
+		}
+		row.in.BillingInterval = interval
+	}
+
+	if v := value("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			errs.Add("user_id", domain.CodeInvalid, "user id must be UUID")
+		}
+		row.in.UserID = userID
+	}
+
+	if v := value("start_date"); v != "" {
+		startDate, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("start_date", domain.CodeInvalid, "start date must be in MM-YYYY format")
+		}
+		row.in.StartDate = startDate
+	}
+
+	if v := value("end_date"); v != "" {
+		endDate, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("end_date", domain.CodeInvalid, "end date must be in MM-YYYY format")
+		}
+		row.in.EndDate = &endDate
+	}
+
+	if errs != nil {
+		return row, errs
+	}
+
+	row.in.SetDefaults()
+
+	return row, row.in.Validate()
+}
+
+// batchItem converts row to batch item, all fields of existing subscription except user are replaced
+func (row importRow) batchItem() domain.BatchItem {
+	if row.id == nil {
+		return domain.BatchItem{Op: domain.BatchOpCreate, Create: row.in}
+	}
+
+	endDate := row.in.EndDate
+
+	return domain.BatchItem{
+		Op: domain.BatchOpUpdate,
+		ID: *row.id,
+		Update: domain.UpdateSubscriptionInput{
+			ServiceName:     &row.in.ServiceName,
+			Price:           &row.in.Price,
+			Currency:        &row.in.Currency,
+			BillingPeriod:   &row.in.BillingPeriod,
+			BillingInterval: &row.in.BillingInterval,
+			StartDate:       &row.in.StartDate,
+			EndDate:         &endDate,
+		},
+	}
+}
@@ -0,0 +1,137 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/list"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// @Summary Export subscriptions
+// @Description Stream all subscriptions matching the list filters as CSV with MM-YYYY dates
+// @Tags subscriptions
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param format query string false "Export format" Enums(csv) default(csv)
+// @Param user_id query string false "User ID"
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Service name prefix"
+// @Param min_price query int false "Minimal price"
+// @Param max_price query int false "Maximal price"
+// @Param active_in query string false "Month subscription is active in (MM-YYYY)"
+// @Param include_deleted query bool false "Include soft deleted subscriptions" default(false)
+// @Param sort query string false "Sort field" Enums(created_at, start_date, price, service_name) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {string} string "CSV with header row"
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/export [get]
+//
+// The write deadline of the connection is extended by pageTimeout for every page,
+// so that long exports are not cut off by the server write timeout.
+func NewExportHandler(log *slog.Logger, repo handlers.SubscriptionRepository, pageTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.csvio.NewExportHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if r.Method != http.MethodGet {
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		query := r.URL.Query()
+
+		if format := query.Get("format"); format != "" && format != "csv" {
+			lib.RespondWithError(w, http.StatusBadRequest, "unsupported format, expected csv")
+			return
+		}
+
+		// limit and cursor of listing are replaced with pages of the maximal size
+		query.Del("limit")
+		query.Del("cursor")
+
+		filter, err := list.ParseListFilter(query)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !list.ScopeListFilter(ctx, &filter) {
+			lib.RespondWithError(w, http.StatusForbidden, "subscriptions of another user are not accessible")
+			return
+		}
+
+		filter.Limit = domain.MaxListLimit
+
+		page, err := repo.ListSubscriptions(ctx, filter)
+		if err != nil {
+			log.ErrorContext(ctx, "error getting subscriptions", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.csv"`)
+		w.WriteHeader(http.StatusOK)
+
+		rc := http.NewResponseController(w)
+		writer := csv.NewWriter(w)
+		writer.Write(columns)
+
+		// pages are written as soon as they are loaded, errors after the header can only be logged
+		for {
+			for i := range page.Items {
+				writer.Write(formatRow(&page.Items[i]))
+			}
+
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				log.ErrorContext(ctx, "error writing subscriptions", "error", err)
+				return
+			}
+
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				log.ErrorContext(ctx, "error flushing subscriptions", "error", err)
+				return
+			}
+
+			if page.NextCursor == "" {
+				return
+			}
+
+			if err := rc.SetWriteDeadline(time.Now().Add(pageTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				log.ErrorContext(ctx, "error extending write deadline", "error", err)
+				return
+			}
+
+			filter.Cursor, err = domain.DecodeListCursor(page.NextCursor)
+			if err != nil {
+				log.ErrorContext(ctx, "error decoding cursor", "error", err)
+				return
+			}
+
+			page, err = repo.ListSubscriptions(ctx, filter)
+			if err != nil {
+				log.ErrorContext(ctx, "error getting subscriptions", "error", err)
+				return
+			}
+		}
+	}
+}
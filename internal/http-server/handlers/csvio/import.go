@@ -0,0 +1,126 @@
+package csvio
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+const maxImportBytes = 10 << 20
+
+// ImportResponse represents numbers of created and updated subscriptions
+type ImportResponse struct {
+	Created int `json:"created" example:"38"`
+	Updated int `json:"updated" example:"2"`
+}
+
+// @Summary Import subscriptions
+// @Description Import subscriptions from CSV with header row and MM-YYYY dates. Every row is validated with
+// @Description create rules and all row errors are reported before anything is saved. Rows without id create
+// @Description subscriptions, rows with id replace all fields of existing subscriptions except user_id.
+// @Description Rows are saved in a single transaction
+// @Tags subscriptions
+// @Accept text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body string true "CSV with columns service_name, price, user_id, start_date and optional id, currency, billing_period, billing_interval, end_date"
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 422 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/import [post]
+func NewImportHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.csvio.NewImportHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if r.Method != http.MethodPost {
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		// operations on many subscriptions are not checked per user, they are available to services and staff only
+		if _, ok := middleware.ScopedUserID(ctx); ok {
+			lib.RespondWithError(w, http.StatusForbidden, "imports are not available to user tokens")
+			return
+		}
+
+		rows, errs, err := parseRows(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid CSV: %s", err))
+			return
+		}
+
+		if errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid subscriptions CSV", errs)
+			return
+		}
+
+		items := make([]domain.BatchItem, 0, len(rows))
+		for _, row := range rows {
+			items = append(items, row.batchItem())
+		}
+
+		results, err := repo.ExecuteBatch(ctx, items, true)
+		if err != nil {
+			log.ErrorContext(ctx, "error importing subscriptions", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		var resp ImportResponse
+
+		for i, result := range results {
+			if result.Err == nil {
+				if items[i].Op == domain.BatchOpCreate {
+					resp.Created++
+				} else {
+					resp.Updated++
+				}
+				continue
+			}
+
+			if errors.Is(result.Err, repository.ErrBatchRolledBack) {
+				continue
+			}
+
+			// row errors of the storage are unknown subscription IDs and invalid merged updates
+			problem := lib.NewProblem(http.StatusUnprocessableEntity, "subscriptions are not imported")
+			problem.Type = lib.ProblemTypeValidation
+
+			var errs domain.ValidationErrors
+			if !errors.As(result.Err, &errs) {
+				errs = domain.ValidationErrors{{Field: "id", Code: domain.CodeInvalid, Message: "subscription not found"}}
+			}
+
+			var rowErrs domain.ValidationErrors
+			for _, fe := range errs {
+				rowErrs.Add(fmt.Sprintf("rows[%d].%s", i+1, fe.Field), fe.Code, fmt.Sprintf("row %d: %s", i+1, fe.Message))
+			}
+			problem.Errors = rowErrs
+
+			lib.RespondWithProblem(w, problem)
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
@@ -8,18 +8,23 @@ import (
 	"github.com/google/uuid"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
-	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
 	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // @Summary Delete subscription
-// @Description Delete subscription by ID
+// @Description Soft delete subscription by ID. Deleted subscription can be restored until it is purged
 // @Tags subscriptions
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param id path string true "Subscription ID"
 // @Success 200 {object} lib.SuccessResponse
-// @Failure 400 {object} lib.ErrorResponse
-// @Failure 404 {object} lib.ErrorResponse
-// @Failure 500 {object} lib.ErrorResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions/{id} [delete]
 func NewDeleteHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -29,7 +34,7 @@ func NewDeleteHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id
 
 		log = log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
 		err := repo.DeleteSubscription(ctx, id)
@@ -38,7 +43,7 @@ func NewDeleteHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id
 				lib.RespondWithError(w, http.StatusBadRequest, err.Error())
 				return
 			}
-			log.Error("error deleting subscription", "error", err)
+			log.ErrorContext(ctx, "error deleting subscription", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
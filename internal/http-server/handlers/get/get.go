@@ -8,19 +8,25 @@ import (
 	"github.com/google/uuid"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
-	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
 	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // @Summary Get subscription
 // @Description Get subscription by ID
 // @Tags subscriptions
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param id path string true "Subscription ID"
 // @Success 200 {object} domain.Subscription
-// @Failure 400 {object} lib.ErrorResponse
-// @Failure 404 {object} lib.ErrorResponse
-// @Failure 500 {object} lib.ErrorResponse
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions/{id} [get]
 func NewGetHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -30,7 +36,7 @@ func NewGetHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uu
 
 		log = log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
 		sub, err := repo.GetSubscriptionByID(ctx, id)
@@ -39,11 +45,12 @@ func NewGetHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uu
 				lib.RespondWithError(w, http.StatusBadRequest, err.Error())
 				return
 			}
-			log.Error("error getting subscription", "error", err)
+			log.ErrorContext(ctx, "error getting subscription", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
+		lib.SetETag(w, sub.Version)
 		lib.RespondWithJSON(w, http.StatusOK, sub)
 	}
 }
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Readiness tells whether the application accepts traffic.
+// It is set once the server is started and reset when shutdown begins,
+// so that load balancers stop routing requests before connections are drained.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load()
+}
+
+// Checker checks storage availability
+type Checker interface {
+	Ping(ctx context.Context) error
+}
+
+// PoolStatsProvider is implemented by storages backed by a connection pool
+type PoolStatsProvider interface {
+	Stats() sql.DBStats
+}
+
+// SchemaVersionProvider is implemented by storages with schema migrations
+type SchemaVersionProvider interface {
+	SchemaVersion(ctx context.Context) (uint, bool, error)
+}
+
+// LivenessResponse represents liveness probe response
+type LivenessResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// ReadinessResponse represents readiness probe response
+type ReadinessResponse struct {
+	Status   string          `json:"status" example:"ok"`
+	Database *DatabaseStatus `json:"database,omitempty"`
+}
+
+// DatabaseStatus represents database state reported by readiness probe
+type DatabaseStatus struct {
+	Status           string     `json:"status" example:"ok"`
+	Error            string     `json:"error,omitempty" example:"context deadline exceeded"`
+	MigrationVersion *uint      `json:"migration_version,omitempty" example:"2"`
+	MigrationDirty   *bool      `json:"migration_dirty,omitempty" example:"false"`
+	Pool             *PoolStats `json:"pool,omitempty"`
+}
+
+// PoolStats represents database connection pool statistics
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections" example:"20"`
+	OpenConnections    int    `json:"open_connections" example:"3"`
+	InUse              int    `json:"in_use" example:"1"`
+	Idle               int    `json:"idle" example:"2"`
+	WaitCount          int64  `json:"wait_count" example:"0"`
+	WaitDuration       string `json:"wait_duration" example:"0s"`
+}
+
+// @Summary Liveness probe
+// @Description Report that the process is up
+// @Tags health
+// @Produce json
+// @Success 200 {object} LivenessResponse
+// @Router /healthz [get]
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lib.RespondWithJSON(w, http.StatusOK, LivenessResponse{Status: StatusOK})
+	}
+}
+
+// @Summary Readiness probe
+// @Description Report whether the application accepts traffic: it is not shutting down and database is reachable
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func NewReadinessHandler(log *slog.Logger, readiness *Readiness, checker Checker, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.health.NewReadinessHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(r.Context())),
+		)
+
+		if !readiness.IsReady() {
+			lib.RespondWithJSON(w, http.StatusServiceUnavailable, ReadinessResponse{Status: StatusShuttingDown})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		resp := ReadinessResponse{Status: StatusOK}
+		db := &DatabaseStatus{Status: StatusOK}
+
+		if err := checker.Ping(ctx); err != nil {
+			log.ErrorContext(ctx, "database is not reachable", "error", err)
+			resp.Status = StatusUnavailable
+			db.Status = StatusUnavailable
+			db.Error = err.Error()
+		}
+
+		if p, ok := checker.(SchemaVersionProvider); ok && db.Status == StatusOK {
+			version, dirty, err := p.SchemaVersion(ctx)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to get migration version", "error", err)
+			} else {
+				db.MigrationVersion = &version
+				db.MigrationDirty = &dirty
+			}
+		}
+
+		if p, ok := checker.(PoolStatsProvider); ok {
+			stats := p.Stats()
+			db.Pool = &PoolStats{
+				MaxOpenConnections: stats.MaxOpenConnections,
+				OpenConnections:    stats.OpenConnections,
+				InUse:              stats.InUse,
+				Idle:               stats.Idle,
+				WaitCount:          stats.WaitCount,
+				WaitDuration:       stats.WaitDuration.String(),
+			}
+		}
+
+		resp.Database = db
+
+		status := http.StatusOK
+		if resp.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		lib.RespondWithJSON(w, status, resp)
+	}
+}
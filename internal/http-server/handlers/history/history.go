@@ -0,0 +1,54 @@
+package history
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// @Summary Get subscription history
+// @Description Get changes of subscription in chronological order with old and new values
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} domain.SubscriptionHistoryRecord
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/{id}/history [get]
+func NewHistoryHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.history.NewHistoryHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		records, err := repo.GetSubscriptionHistory(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				lib.RespondWithError(w, http.StatusNotFound, "subscription history not found")
+				return
+			}
+			log.ErrorContext(ctx, "error getting subscription history", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, records)
+	}
+}
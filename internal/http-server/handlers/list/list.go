@@ -1,20 +1,45 @@
 package list
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // @Summary List subscriptions
-// @Description Get all subscriptions
+// @Description Get subscriptions page filtered and sorted by query parameters.
+// @Description Pass next_cursor of the response as cursor parameter to get the next page.
 // @Tags subscriptions
 // @Produce json
-// @Success 200 {array} domain.Subscription
-// @Failure 500 {object} lib.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param user_id query string false "User ID"
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Service name prefix"
+// @Param min_price query int false "Minimal price"
+// @Param max_price query int false "Maximal price"
+// @Param active_in query string false "Month subscription is active in (MM-YYYY)"
+// @Param include_deleted query bool false "Include soft deleted subscriptions" default(false)
+// @Param sort query string false "Sort field" Enums(created_at, start_date, price, service_name) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Param limit query int false "Page size" default(50) maximum(500)
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} domain.SubscriptionsPage
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions [get]
 func NewListHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -24,16 +49,140 @@ func NewListHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http
 
 		log = log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
-		subs, err := repo.ListSubscriptions(ctx)
+		filter, err := ParseListFilter(r.URL.Query())
 		if err != nil {
-			log.Error("error getting subscriptions", "error", err)
+			lib.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !ScopeListFilter(ctx, &filter) {
+			lib.RespondWithError(w, http.StatusForbidden, "subscriptions of another user are not accessible")
+			return
+		}
+
+		page, err := repo.ListSubscriptions(ctx, filter)
+		if err != nil {
+			log.ErrorContext(ctx, "error getting subscriptions", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
-		lib.RespondWithJSON(w, http.StatusOK, subs)
+		lib.RespondWithJSON(w, http.StatusOK, page)
+	}
+}
+
+// ScopeListFilter restricts filter to the user the client is restricted to.
+// Returns false if filter selects subscriptions of another user.
+func ScopeListFilter(ctx context.Context, filter *domain.ListSubscriptionsFilter) bool {
+	userID, ok := middleware.ScopedUserID(ctx)
+	if !ok {
+		return true
+	}
+
+	if filter.UserID != nil && *filter.UserID != userID {
+		return false
+	}
+
+	filter.UserID = &userID
+
+	return true
+}
+
+// ParseListFilter parses subscriptions list filter, sorting and pagination from query parameters
+func ParseListFilter(query url.Values) (domain.ListSubscriptionsFilter, error) {
+	filter := domain.ListSubscriptionsFilter{
+		Sort:  domain.SortByCreatedAt,
+		Order: domain.OrderDesc,
+		Limit: domain.DefaultListLimit,
+	}
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := query.Get("service_name"); v != "" {
+		filter.ServiceName = &v
+	}
+
+	if v := query.Get("service_name_prefix"); v != "" {
+		filter.ServiceNamePrefix = &v
+	}
+
+	if v := query.Get("min_price"); v != "" {
+		minPrice, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid min_price")
+		}
+		filter.MinPrice = &minPrice
+	}
+
+	if v := query.Get("max_price"); v != "" {
+		maxPrice, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid max_price")
+		}
+		filter.MaxPrice = &maxPrice
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
 	}
+
+	if v := query.Get("active_in"); v != "" {
+		activeIn, err := domain.ParseMonthYear(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid active_in, expected MM-YYYY")
+		}
+		filter.ActiveIn = &activeIn
+	}
+
+	if v := query.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid include_deleted")
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+
+	if v := query.Get("sort"); v != "" {
+		if !domain.IsValidSortField(v) {
+			return filter, fmt.Errorf("invalid sort")
+		}
+		filter.Sort = v
+	}
+
+	if v := query.Get("order"); v != "" {
+		if v != domain.OrderAsc && v != domain.OrderDesc {
+			return filter, fmt.Errorf("invalid order")
+		}
+		filter.Order = v
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > domain.MaxListLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", domain.MaxListLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.DecodeListCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return filter, fmt.Errorf("cursor does not match sort and order")
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,57 @@
+package purge
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// @Summary Hard delete subscription
+// @Description Permanently delete subscription by ID, including soft deleted one. It can not be restored,
+// @Description its history is kept
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} lib.SuccessResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/{id}/purge [delete]
+func NewPurgeHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.purge.NewPurgeHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		err := repo.PurgeSubscription(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				lib.RespondWithError(w, http.StatusNotFound, "subscription not found")
+				return
+			}
+			log.ErrorContext(ctx, "error purging subscription", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		log.InfoContext(ctx, "subscription purged", "id", id, "actor", reqctx.GetActor(ctx))
+
+		lib.RespondWithJSON(w, http.StatusOK, lib.NewSuccessResponse("success"))
+	}
+}
@@ -0,0 +1,169 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+func NewExchangeRatesHandler(log *slog.Logger, repo handlers.ExchangeRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h := NewListHandler(log, repo)
+			h.ServeHTTP(w, r)
+		case http.MethodPut:
+			h := NewUpsertHandler(log, repo)
+			h.ServeHTTP(w, r)
+		default:
+			lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// @Summary List exchange rates
+// @Description Get exchange rates used to convert subscription prices. Rate is the price of one unit of currency in RUB,
+// @Description valid from the month until the next rate of the currency
+// @Tags exchange-rates
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Success 200 {array} domain.ExchangeRate
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /exchange-rates [get]
+func NewListHandler(log *slog.Logger, repo handlers.ExchangeRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.rates.NewListHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		rates, err := repo.ListExchangeRates(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "error getting exchange rates", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, rates)
+	}
+}
+
+// @Summary Upsert exchange rates
+// @Description Add exchange rates or replace existing ones for the same currency and month
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body []domain.ExchangeRate true "Exchange rates"
+// @Success 200 {object} lib.SuccessResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /exchange-rates [put]
+func NewUpsertHandler(log *slog.Logger, repo handlers.ExchangeRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.rates.NewUpsertHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		// exchange rates are shared by all users
+		if _, ok := middleware.ScopedUserID(ctx); ok {
+			lib.RespondWithError(w, http.StatusForbidden, "exchange rates can not be changed with user tokens")
+			return
+		}
+
+		var rates []domain.ExchangeRate
+		err := json.NewDecoder(r.Body).Decode(&rates)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, "invalid exchange rates input")
+			return
+		}
+
+		if errs := validateExchangeRates(rates); errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid exchange rates input", errs)
+			return
+		}
+
+		err = repo.UpsertExchangeRates(ctx, rates)
+		if err != nil {
+			log.ErrorContext(ctx, "error upserting exchange rates", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.RespondWithJSON(w, http.StatusOK, lib.NewSuccessResponse("success"))
+	}
+}
+
+// LoadFile upserts exchange rates from JSON file in the format of upsert request body
+func LoadFile(ctx context.Context, repo handlers.ExchangeRateRepository, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var rates []domain.ExchangeRate
+	err = json.NewDecoder(f).Decode(&rates)
+	if err != nil {
+		return 0, fmt.Errorf("invalid exchange rates file: %w", err)
+	}
+
+	if errs := validateExchangeRates(rates); errs != nil {
+		return 0, errs
+	}
+
+	err = repo.UpsertExchangeRates(ctx, rates)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func validateExchangeRates(rates []domain.ExchangeRate) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+
+	for i, rate := range rates {
+		if !domain.IsValidCurrency(rate.Currency) {
+			errs.Add(fmt.Sprintf("[%d].currency", i), domain.CodeInvalid, "currency must be ISO 4217 code")
+		} else if rate.Currency == domain.DefaultCurrency {
+			errs.Add(fmt.Sprintf("[%d].currency", i), domain.CodeInvalid, fmt.Sprintf("rate of %s is always 1", domain.DefaultCurrency))
+		}
+
+		if rate.Month.Time().IsZero() {
+			errs.Add(fmt.Sprintf("[%d].month", i), domain.CodeRequired, "month is required")
+		}
+
+		if rate.Rate <= 0 {
+			errs.Add(fmt.Sprintf("[%d].rate", i), domain.CodeMin, "rate must be positive")
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,56 @@
+package restore
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// @Summary Restore subscription
+// @Description Restore soft deleted subscription by ID until it is purged
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
+// @Router /subscriptions/{id}/restore [post]
+func NewRestoreHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.handlers.restore.NewRestoreHandler"
+
+		ctx := r.Context()
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		sub, err := repo.RestoreSubscription(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				lib.RespondWithError(w, http.StatusNotFound, "deleted subscription not found")
+				return
+			}
+			log.ErrorContext(ctx, "error restoring subscription", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		lib.SetETag(w, sub.Version)
+		lib.RespondWithJSON(w, http.StatusOK, sub)
+	}
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/l-golofastov/subscriptions-manager/internal/domain"
@@ -10,8 +12,33 @@ import (
 type SubscriptionRepository interface {
 	CreateSubscription(ctx context.Context, in domain.CreateSubscriptionInput) (*domain.Subscription, error)
 	GetSubscriptionByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
-	ListSubscriptions(ctx context.Context) ([]domain.Subscription, error)
+	GetSubscriptionOwner(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	ListSubscriptions(ctx context.Context, in domain.ListSubscriptionsFilter) (*domain.SubscriptionsPage, error)
 	UpdateSubscription(ctx context.Context, id uuid.UUID, in domain.UpdateSubscriptionInput) (*domain.Subscription, error)
 	DeleteSubscription(ctx context.Context, id uuid.UUID) error
-	SumSubscriptionsPrices(ctx context.Context, in domain.SumSubscriptionsFilter) (int, error)
+	RestoreSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	PurgeSubscription(ctx context.Context, id uuid.UUID) error
+	GetSubscriptionHistory(ctx context.Context, id uuid.UUID) ([]domain.SubscriptionHistoryRecord, error)
+	SumSubscriptionsPrices(ctx context.Context, in domain.SumSubscriptionsFilter) (*domain.SubscriptionsSum, error)
+	SubscriptionsBreakdown(ctx context.Context, in domain.BreakdownFilter) (*domain.Breakdown, error)
+	ExecuteBatch(ctx context.Context, items []domain.BatchItem, atomic bool) ([]domain.BatchItemResult, error)
+}
+
+type ExchangeRateRepository interface {
+	ListExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error)
+	UpsertExchangeRates(ctx context.Context, rates []domain.ExchangeRate) error
+}
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, subject, key, requestHash string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, subject, key string, statusCode int, headers json.RawMessage, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, subject, key string) error
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, in domain.CreateAPIKeyInput) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
 }
@@ -1,6 +1,7 @@
 package subscriptions
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -10,9 +11,15 @@ import (
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/create"
 	del "github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/delete"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/get"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/history"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/list"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/purge"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/restore"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers/update"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 func NewSubscriptionByIDHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
@@ -25,6 +32,9 @@ func NewSubscriptionByIDHandler(log *slog.Logger, repo handlers.SubscriptionRepo
 		}
 
 		idStr := strings.TrimPrefix(r.URL.Path, prefix)
+
+		idStr, action, _ := strings.Cut(idStr, "/")
+
 		if idStr == "" {
 			lib.RespondWithError(w, http.StatusBadRequest, "empty path parameters")
 			return
@@ -36,7 +46,50 @@ func NewSubscriptionByIDHandler(log *slog.Logger, repo handlers.SubscriptionRepo
 			return
 		}
 
-		log.Info(idStr)
+		// users restricted to their subscriptions get not found for subscriptions of others
+		if _, ok := middleware.ScopedUserID(r.Context()); ok {
+			owner, err := repo.GetSubscriptionOwner(r.Context(), id)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				log.ErrorContext(r.Context(), "error getting subscription owner", "error", err, "request_id", reqctx.GetRequestID(r.Context()))
+				lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if err != nil || !middleware.AllowsUser(r.Context(), owner) {
+				lib.RespondWithError(w, http.StatusNotFound, "subscription not found")
+				return
+			}
+		}
+
+		switch action {
+		case "":
+		case "restore":
+			if r.Method != http.MethodPost {
+				lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			h := restore.NewRestoreHandler(log, repo, id)
+			h.ServeHTTP(w, r)
+			return
+		case "purge":
+			if r.Method != http.MethodDelete {
+				lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			h := purge.NewPurgeHandler(log, repo, id)
+			h.ServeHTTP(w, r)
+			return
+		case "history":
+			if r.Method != http.MethodGet {
+				lib.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			h := history.NewHistoryHandler(log, repo, id)
+			h.ServeHTTP(w, r)
+			return
+		default:
+			lib.RespondWithError(w, http.StatusNotFound, "invalid URL path")
+			return
+		}
 
 		switch r.Method {
 		case http.MethodGet:
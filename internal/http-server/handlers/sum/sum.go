@@ -1,30 +1,48 @@
 package sum
 
 import (
-	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 
+	"github.com/google/uuid"
 	"github.com/l-golofastov/subscriptions-manager/internal/domain"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // SuccessSumResponse represents success summarizing subscriptions prices response with amount in body
+// and cost of every subscription billed within the period
 type SuccessSumResponse struct {
-	Amount int `json:"amount" example:"1000"`
+	Currency      string                    `json:"currency" example:"RUB"`
+	Amount        int                       `json:"amount" example:"2994"`
+	Subscriptions []domain.SubscriptionCost `json:"subscriptions"`
 }
 
 // @Summary Sum subscriptions prices
-// @Description Calculate total price of subscriptions
+// @Description Calculate total price of subscriptions: monthly price multiplied by number of months
+// @Description each subscription is active within the period. Prices are converted to the requested currency
+// @Description with exchange rates valid in each billed month. Every filter is optional,
+// @Description user_id and service_name may be repeated to select several users or services
 // @Tags subscriptions
-// @Accept json
 // @Produce json
-// @Param input body domain.SumSubscriptionsFilter true "Sum filter"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param user_id query []string false "User IDs" collectionFormat(multi)
+// @Param service_name query []string false "Service names" collectionFormat(multi)
+// @Param from query string false "First month of the period (MM-YYYY), subscriptions start by default"
+// @Param to query string false "Last month of the period (MM-YYYY), current month by default"
+// @Param currency query string false "Currency of the sum" default(RUB)
 // @Success 200 {object} SuccessSumResponse
-// @Failure 400 {object} lib.ErrorResponse
-// @Failure 500 {object} lib.ErrorResponse
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 422 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions/sum [get]
 func NewSumHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -32,9 +50,9 @@ func NewSumHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.
 
 		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
 		if r.Method != http.MethodGet {
@@ -42,20 +60,83 @@ func NewSumHandler(log *slog.Logger, repo handlers.SubscriptionRepository) http.
 			return
 		}
 
-		var filter domain.SumSubscriptionsFilter
-		err := json.NewDecoder(r.Body).Decode(&filter)
-		if err != nil {
-			lib.RespondWithError(w, http.StatusBadRequest, "invalid sum subscriptions prices filter")
+		filter, errs := parseSumFilter(r.URL.Query())
+		if errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid sum subscriptions prices filter", errs)
 			return
 		}
 
-		amount, err := repo.SumSubscriptionsPrices(ctx, filter)
+		// users restricted to their subscriptions sum only them
+		if userID, ok := middleware.ScopedUserID(ctx); ok {
+			for _, id := range filter.UserIDs {
+				if id != userID {
+					lib.RespondWithError(w, http.StatusForbidden, "subscriptions of another user are not accessible")
+					return
+				}
+			}
+			filter.UserIDs = []uuid.UUID{userID}
+		}
+
+		sum, err := repo.SumSubscriptionsPrices(ctx, filter)
 		if err != nil {
-			log.Error("error getting sum subscriptions prices", "error", err)
+			var noRateErr *domain.NoExchangeRateError
+			if errors.As(err, &noRateErr) {
+				lib.RespondWithError(w, http.StatusUnprocessableEntity, noRateErr.Error())
+				return
+			}
+			log.ErrorContext(ctx, "error getting sum subscriptions prices", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
-		lib.RespondWithJSON(w, http.StatusOK, SuccessSumResponse{Amount: amount})
+		lib.RespondWithJSON(w, http.StatusOK, SuccessSumResponse{
+			Currency:      sum.Currency,
+			Amount:        sum.Amount,
+			Subscriptions: sum.Subscriptions,
+		})
+	}
+}
+
+// parseSumFilter parses sum filter from query parameters, sets defaults and validates it
+func parseSumFilter(query url.Values) (domain.SumSubscriptionsFilter, domain.ValidationErrors) {
+	var filter domain.SumSubscriptionsFilter
+
+	var errs domain.ValidationErrors
+
+	for _, v := range query["user_id"] {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			errs.Add("user_id", domain.CodeInvalid, "user id must be UUID")
+			break
+		}
+		filter.UserIDs = append(filter.UserIDs, userID)
+	}
+
+	filter.ServiceNames = query["service_name"]
+
+	if v := query.Get("from"); v != "" {
+		from, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("from", domain.CodeInvalid, "from must be in MM-YYYY format")
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := domain.ParseMonthYear(v)
+		if err != nil {
+			errs.Add("to", domain.CodeInvalid, "to must be in MM-YYYY format")
+		}
+		filter.To = &to
+	}
+
+	filter.Currency = query.Get("currency")
+
+	if errs != nil {
+		return filter, errs
 	}
+
+	filter.SetDefaults()
+
+	return filter, filter.Validate()
 }
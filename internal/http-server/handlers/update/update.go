@@ -10,21 +10,29 @@ import (
 	"github.com/l-golofastov/subscriptions-manager/internal/domain"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
 	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
-	"github.com/l-golofastov/subscriptions-manager/internal/http-server/middleware"
 	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 // @Summary Update subscription
-// @Description Update subscription by ID
+// @Description Update subscription by ID. If If-Match header is set, subscription is updated only if its ETag matches
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param id path string true "Subscription ID"
 // @Param input body domain.UpdateSubscriptionInput true "Update subscription"
+// @Param If-Match header string false "ETag of the subscription returned by get or previous update"
 // @Success 200 {object} domain.Subscription
-// @Failure 400 {object} lib.ErrorResponse
-// @Failure 404 {object} lib.ErrorResponse
-// @Failure 500 {object} lib.ErrorResponse
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} lib.Problem
+// @Failure 401 {object} lib.Problem
+// @Failure 403 {object} lib.Problem
+// @Failure 404 {object} lib.Problem
+// @Failure 412 {object} lib.Problem
+// @Failure 429 {object} lib.Problem
+// @Failure 500 {object} lib.Problem
 // @Router /subscriptions/{id} [patch]
 func NewUpdateHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id uuid.UUID) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -34,7 +42,7 @@ func NewUpdateHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id
 
 		log = log.With(
 			slog.String("op", op),
-			slog.String("request_id", middleware.GetRequestID(ctx)),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
 		)
 
 		var in domain.UpdateSubscriptionInput
@@ -44,17 +52,39 @@ func NewUpdateHandler(log *slog.Logger, repo handlers.SubscriptionRepository, id
 			return
 		}
 
+		if errs := in.Validate(); errs != nil {
+			lib.RespondWithValidationErrors(w, "invalid update subscription input", errs)
+			return
+		}
+
+		in.ExpectedVersion, err = lib.ParseIfMatch(r)
+		if err != nil {
+			lib.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		sub, err := repo.UpdateSubscription(ctx, id, in)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				lib.RespondWithError(w, http.StatusBadRequest, err.Error())
 				return
 			}
-			log.Error("error updating subscription", "error", err)
+			if errors.Is(err, repository.ErrVersionMismatch) {
+				lib.RespondWithError(w, http.StatusPreconditionFailed, "subscription was modified, ETag does not match If-Match")
+				return
+			}
+
+			var errs domain.ValidationErrors
+			if errors.As(err, &errs) {
+				lib.RespondWithValidationErrors(w, "invalid update subscription input", errs)
+				return
+			}
+			log.ErrorContext(ctx, "error updating subscription", "error", err)
 			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
+		lib.SetETag(w, sub.Version)
 		lib.RespondWithJSON(w, http.StatusOK, sub)
 	}
 }
@@ -3,11 +3,27 @@ package lib
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+const (
+	// ProblemTypeDefault is used when problem has no additional semantics beyond HTTP status
+	ProblemTypeDefault = "about:blank"
+	// ProblemTypeValidation is used when request input has invalid fields listed in errors
+	ProblemTypeValidation = "/problems/validation-error"
 )
 
-// ErrorResponse represents error response
-type ErrorResponse struct {
-	ErrorMessage string `json:"error" example:"error message"`
+const requestIDHeader = "X-Request-ID"
+
+// Problem represents RFC 7807 problem details error response
+type Problem struct {
+	Type      string              `json:"type" example:"/problems/validation-error"`
+	Title     string              `json:"title" example:"Bad Request"`
+	Status    int                 `json:"status" example:"400"`
+	Detail    string              `json:"detail,omitempty" example:"invalid subscription input"`
+	RequestID string              `json:"request_id,omitempty" example:"4f1e2f6a-8f4e-4a55-9f0e-0d0b5b7c9a11"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
 }
 
 // SuccessResponse represents success response
@@ -15,10 +31,16 @@ type SuccessResponse struct {
 	Message string `json:"result" example:"success"`
 }
 
-func NewErrorResponse(error string) ErrorResponse {
-	errorResponse := ErrorResponse{error}
+// NewProblem creates problem of default type with title of the HTTP status
+func NewProblem(statusCode int, detail string) Problem {
+	problem := Problem{
+		Type:   ProblemTypeDefault,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
 
-	return errorResponse
+	return problem
 }
 
 func NewSuccessResponse(result string) SuccessResponse {
@@ -33,6 +55,27 @@ func RespondWithJSON(w http.ResponseWriter, statusCode int, payload interface{})
 	json.NewEncoder(w).Encode(payload) //TODO: handle error
 }
 
+// RespondWithProblem writes problem as application/problem+json.
+// Request ID is taken from the response header set by request ID middleware.
+func RespondWithProblem(w http.ResponseWriter, problem Problem) {
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(requestIDHeader)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem) //TODO: handle error
+}
+
 func RespondWithError(w http.ResponseWriter, statusCode int, errorMessage string) {
-	RespondWithJSON(w, statusCode, NewErrorResponse(errorMessage))
+	RespondWithProblem(w, NewProblem(statusCode, errorMessage))
+}
+
+// RespondWithValidationErrors responds with 400 problem listing every invalid field
+func RespondWithValidationErrors(w http.ResponseWriter, detail string, errs domain.ValidationErrors) {
+	problem := NewProblem(http.StatusBadRequest, detail)
+	problem.Type = ProblemTypeValidation
+	problem.Errors = errs
+
+	RespondWithProblem(w, problem)
 }
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/jwt"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	// AdminSubject identifies requests made with the admin key from configuration
+	AdminSubject = "admin"
+
+	// apiKeyTouchInterval limits writes of key last usage time to one per interval
+	apiKeyTouchInterval = time.Minute
+)
+
+type identityKeyType struct{}
+
+var identityKey identityKeyType
+
+// WithIdentity stores authenticated client of the request, the subject is used as actor of changes
+func WithIdentity(ctx context.Context, identity domain.Identity) context.Context {
+	ctx = context.WithValue(ctx, identityKey, identity)
+	return reqctx.WithActor(ctx, identity.Subject)
+}
+
+func GetIdentity(ctx context.Context) (domain.Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(domain.Identity)
+	return identity, ok
+}
+
+// ScopedUserID returns the user whose subscriptions only are accessible to the client of the request
+func ScopedUserID(ctx context.Context) (uuid.UUID, bool) {
+	identity, ok := GetIdentity(ctx)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	return identity.ScopedUserID()
+}
+
+// AllowsUser reports whether the client of the request may access subscriptions of the user
+func AllowsUser(ctx context.Context, userID uuid.UUID) bool {
+	scoped, ok := ScopedUserID(ctx)
+	return !ok || scoped == userID
+}
+
+// NewAuthMiddleware requires Authorization header with a bearer JWT accepted by verifier, or X-API-Key header
+// with a not revoked key or the admin key on every request except ones to publicPaths.
+// Paths ending with slash match all paths under them. Nil verifier disables JWT authentication.
+func NewAuthMiddleware(next http.Handler, log *slog.Logger, repo handlers.APIKeyRepository, verifier *jwt.Verifier, adminKey string, publicPaths ...string) http.Handler {
+	var adminHash []byte
+	if adminKey != "" {
+		adminHash = []byte(domain.HashAPIKey(adminKey))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.middleware.NewAuthMiddleware"
+
+		if isPublicPath(r.URL.Path, publicPaths) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if authorization := r.Header.Get("Authorization"); authorization != "" && verifier != nil {
+			bearer, ok := strings.CutPrefix(authorization, "Bearer ")
+			if !ok {
+				respondUnauthorized(w, "bearer token is required")
+				return
+			}
+
+			identity, err := verifyBearer(verifier, bearer)
+			if err != nil {
+				respondUnauthorized(w, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(ctx, identity)))
+			return
+		}
+
+		token := r.Header.Get(APIKeyHeader)
+		if token == "" {
+			respondUnauthorized(w, "API key or bearer token is required")
+			return
+		}
+
+		hash := domain.HashAPIKey(token)
+
+		if adminHash != nil && subtle.ConstantTimeCompare([]byte(hash), adminHash) == 1 {
+			identity := domain.Identity{Subject: AdminSubject, Role: domain.RoleAdmin}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(ctx, identity)))
+			return
+		}
+
+		key, err := repo.GetAPIKeyByHash(ctx, hash)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				respondUnauthorized(w, "invalid API key")
+				return
+			}
+			log.ErrorContext(ctx, "error getting API key", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) >= apiKeyTouchInterval {
+			if err := repo.TouchAPIKey(ctx, key.ID); err != nil {
+				log.ErrorContext(ctx, "error updating API key last usage", "error", err)
+			}
+		}
+
+		identity := domain.Identity{Subject: "api_key:" + key.ID.String(), Role: key.Role}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(ctx, identity)))
+	})
+}
+
+// verifyBearer returns identity of the token subject. Tokens without role belong to end users,
+// who are editors of their own subscriptions, so the subject must be user ID.
+// Tokens with role belong to staff and are not restricted to a user.
+func verifyBearer(verifier *jwt.Verifier, token string) (domain.Identity, error) {
+	claims, err := verifier.Verify(token, time.Now())
+	if err != nil {
+		return domain.Identity{}, err
+	}
+
+	identity := domain.Identity{Subject: "user:" + claims.Subject, Role: domain.Role(claims.Role)}
+
+	if claims.Role != "" {
+		if !identity.Role.IsValid() {
+			return domain.Identity{}, errors.New("invalid token: unknown role")
+		}
+		return identity, nil
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return domain.Identity{}, errors.New("invalid token: subject must be user ID")
+	}
+
+	identity.Role = domain.RoleEditor
+	identity.UserID = &userID
+
+	return identity, nil
+}
+
+func isPublicPath(path string, publicPaths []string) bool {
+	for _, public := range publicPaths {
+		if path == public || strings.HasSuffix(public, "/") && strings.HasPrefix(path, public) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func respondUnauthorized(w http.ResponseWriter, detail string) {
+	w.Header().Add("WWW-Authenticate", `Bearer`)
+	w.Header().Add("WWW-Authenticate", `ApiKey header="`+APIKeyHeader+`"`)
+	lib.RespondWithError(w, http.StatusUnauthorized, detail)
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/handlers"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// replayedHeaders are headers of stored response returned with replayed one
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// recordingResponseWriter keeps a copy of the written response body
+type recordingResponseWriter struct {
+	responseWriter
+	body bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// NewIdempotencyMiddleware makes POST requests with Idempotency-Key header safe to retry.
+// Successful response is stored for ttl and replayed to requests with the same key and body,
+// reusing the key with another body is rejected with 422.
+// Keys are scoped by the authenticated client, the same key of another client is a different key.
+func NewIdempotencyMiddleware(next http.Handler, log *slog.Logger, repo handlers.IdempotencyRepository, ttl time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.middleware.NewIdempotencyMiddleware"
+
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		if len(key) > maxIdempotencyKeyLength {
+			lib.RespondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				lib.RespondWithError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+
+			lib.RespondWithError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		identity, _ := GetIdentity(ctx)
+		subject := identity.Subject
+
+		record, reserved, err := repo.ReserveIdempotencyKey(ctx, subject, key, requestHash, ttl)
+		if err != nil {
+			log.ErrorContext(ctx, "error reserving idempotency key", "error", err)
+			lib.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				lib.RespondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with another request")
+			case record.InProgress():
+				lib.RespondWithError(w, http.StatusConflict, "request with the same Idempotency-Key is in progress")
+			default:
+				replayHeaders(w, record.ResponseHeaders)
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.ResponseBody)
+			}
+			return
+		}
+
+		rw := &recordingResponseWriter{
+			responseWriter: responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			},
+		}
+
+		completed := false
+
+		// the key is released even if request is cancelled or panics, so that it can be retried
+		defer func() {
+			if completed {
+				return
+			}
+			if err := repo.ReleaseIdempotencyKey(context.WithoutCancel(ctx), subject, key); err != nil {
+				log.ErrorContext(ctx, "error releasing idempotency key", "error", err)
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+
+		// only successful responses are replayed, failed requests may be retried with the same key
+		if rw.statusCode < 200 || rw.statusCode >= 300 {
+			return
+		}
+
+		headers := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				headers[name] = v
+			}
+		}
+
+		headersJSON, err := json.Marshal(headers)
+		if err != nil {
+			log.ErrorContext(ctx, "error encoding idempotent response headers", "error", err)
+			return
+		}
+
+		err = repo.CompleteIdempotencyKey(context.WithoutCancel(ctx), subject, key, rw.statusCode, headersJSON, rw.body.Bytes())
+		if err != nil {
+			log.ErrorContext(ctx, "error storing idempotent response", "error", err)
+			return
+		}
+
+		completed = true
+	})
+}
+
+// replayHeaders sets stored headers of the response, records stored without headers are JSON responses
+func replayHeaders(w http.ResponseWriter, stored json.RawMessage) {
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	if len(stored) > 0 {
+		if err := json.Unmarshal(stored, &headers); err != nil {
+			headers = map[string]string{"Content-Type": "application/json"}
+		}
+	}
+
+	for name, v := range headers {
+		w.Header().Set(name, v)
+	}
+}
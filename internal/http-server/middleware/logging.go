@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
 type responseWriter struct {
@@ -17,8 +19,24 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func NewLoggingMiddleware(next http.Handler, log *slog.Logger) http.Handler {
+// Unwrap returns the wrapped writer, so that http.ResponseController reaches Flush and deadlines of the connection
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// NewLoggingMiddleware logs every request except ones to skipPaths, e.g. health probes
+func NewLoggingMiddleware(next http.Handler, log *slog.Logger, skipPaths ...string) http.Handler {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = struct{}{}
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := skip[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		start := time.Now()
 
 		rw := &responseWriter{
@@ -29,11 +47,11 @@ func NewLoggingMiddleware(next http.Handler, log *slog.Logger) http.Handler {
 		next.ServeHTTP(rw, r)
 
 		duration := time.Since(start)
-		requestID := GetRequestID(r.Context())
+		requestID := reqctx.GetRequestID(r.Context())
 
 		info := fmt.Sprintf("method=%s path=%s status=%d duration=%s request_id=%s",
 			r.Method, r.URL.Path, rw.statusCode, duration, requestID)
 
-		log.Info(info)
+		log.InfoContext(r.Context(), info)
 	})
 }
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/metrics"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type routeKeyType struct{}
+
+var routeKey routeKeyType
+
+// NewMetricsMiddleware records request count and duration labelled by route template
+// and names the server span of the request by the route.
+// It wraps authentication too, so that rejected requests are recorded. The route is taken
+// from the pattern matched by the mux wrapped with NewRouteMiddleware, requests not reaching it are unmatched.
+func NewMetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rw := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		route := "unmatched"
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), routeKey, &route)))
+
+		metrics.ObserveHTTPRequest(r.Method, route, rw.statusCode, time.Since(start))
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	})
+}
+
+// NewRouteMiddleware passes the route template of the request to NewMetricsMiddleware.
+// It must wrap http.ServeMux directly, since the mux sets the matched pattern on the request it serves.
+func NewRouteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		if route, ok := r.Context().Value(routeKey).(*string); ok {
+			*route = routeTemplate(r)
+		}
+	})
+}
+
+// resourcePatterns are subtree patterns whose first path segment is a resource ID.
+// It is templated as {id} even if malformed, so that the route is the same as of a valid ID
+// and the handler, not the policy, rejects it.
+var resourcePatterns = map[string]bool{
+	"/subscriptions/": true,
+	"/api-keys/":      true,
+}
+
+// routeTemplate returns matched route with path parameters replaced by placeholders,
+// so that labels do not contain raw IDs, e.g. /subscriptions/{id}
+func routeTemplate(r *http.Request) string {
+	if r.Pattern == "" {
+		return "unmatched"
+	}
+
+	if !strings.HasSuffix(r.Pattern, "/") || r.Pattern == r.URL.Path {
+		return r.Pattern
+	}
+
+	rest := strings.Split(strings.TrimPrefix(r.URL.Path, r.Pattern), "/")
+	for i, segment := range rest {
+		if i == 0 && segment != "" && resourcePatterns[r.Pattern] {
+			rest[i] = "{id}"
+			continue
+		}
+		rest[i] = segmentTemplate(segment)
+	}
+
+	return r.Pattern + strings.Join(rest, "/")
+}
+
+func segmentTemplate(segment string) string {
+	if _, err := uuid.Parse(segment); err == nil {
+		return "{id}"
+	}
+
+	for _, c := range segment {
+		if (c < 'a' || c > 'z') && c != '_' {
+			return "{param}"
+		}
+	}
+
+	return segment
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+)
+
+// Policy maps "METHOD route" to the minimal role allowed to call it.
+// Routes are templates with placeholders of path parameters, e.g. "GET /subscriptions/{id}".
+type Policy map[string]domain.Role
+
+// RequiredRole returns the minimal role allowed to call the route, routes missing in the policy require admin
+func (p Policy) RequiredRole(method, route string) domain.Role {
+	if role, ok := p[method+" "+route]; ok {
+		return role
+	}
+
+	return domain.RoleAdmin
+}
+
+// Allows reports whether the identity may call the route
+func (p Policy) Allows(identity domain.Identity, method, route string) bool {
+	return identity.Role.Includes(p.RequiredRole(method, route))
+}
+
+// NewPolicyMiddleware rejects requests of clients whose role is lower than required by policy for the route.
+// It must wrap handlers registered in http.ServeMux, since the route is taken from the pattern matched by the mux.
+// Requests without identity are passed, so endpoints stay available when authentication is disabled.
+func NewPolicyMiddleware(next http.Handler, policy Policy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := GetIdentity(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := routeTemplate(r)
+
+		if !policy.Allows(identity, r.Method, route) {
+			detail := fmt.Sprintf("%s %s requires %s role", r.Method, route, policy.RequiredRole(r.Method, route))
+			lib.RespondWithError(w, http.StatusForbidden, detail)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
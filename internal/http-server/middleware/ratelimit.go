@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/http-server/lib"
+	"github.com/l-golofastov/subscriptions-manager/internal/ratelimit"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// NewRateLimitMiddleware limits requests of every client with token buckets kept in store.
+// Clients are identified by subject of the authenticated identity or by IP address.
+// Routes listed in routeLimits ("METHOD route" as in Policy) have buckets of their own,
+// other routes share the bucket with defaultLimit.
+// It must wrap handlers registered in http.ServeMux, since the route is taken from the pattern matched by the mux.
+// Requests are passed if the store fails, so that its outage does not stop the service.
+func NewRateLimitMiddleware(next http.Handler, log *slog.Logger, store ratelimit.Store, defaultLimit ratelimit.Limit, routeLimits map[string]ratelimit.Limit) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.middleware.NewRateLimitMiddleware"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(ctx)),
+		)
+
+		key := rateLimitClient(r)
+		limit := defaultLimit
+
+		route := r.Method + " " + routeTemplate(r)
+		if routeLimit, ok := routeLimits[route]; ok {
+			key += " " + route
+			limit = routeLimit
+		}
+
+		if takeRateLimitToken(w, r, log, store, key, limit) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// NewIPRateLimitMiddleware limits requests from every IP address before authentication,
+// so that guessing API keys and lookups of them are limited too. Requests to skipPaths, e.g. health probes, are not limited.
+// Requests are passed if the store fails, so that its outage does not stop the service.
+func NewIPRateLimitMiddleware(next http.Handler, log *slog.Logger, store ratelimit.Store, limit ratelimit.Limit, skipPaths ...string) http.Handler {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = struct{}{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http-server.middleware.NewIPRateLimitMiddleware"
+
+		if _, ok := skip[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", reqctx.GetRequestID(r.Context())),
+		)
+
+		// the bucket is separate from the one of unauthenticated clients in NewRateLimitMiddleware
+		if takeRateLimitToken(w, r, log, store, "ip-all:"+clientIP(r), limit) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// takeRateLimitToken takes a token of key from store and sets RateLimit headers.
+// It responds with 429 and returns false if the limit is exceeded.
+func takeRateLimitToken(w http.ResponseWriter, r *http.Request, log *slog.Logger, store ratelimit.Store, key string, limit ratelimit.Limit) bool {
+	ctx := r.Context()
+
+	result, err := store.Take(ctx, key, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "error taking rate limit token", "error", err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+ceilSeconds(limit.Period))
+
+	if !result.Allowed {
+		w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+		lib.RespondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+
+	return true
+}
+
+// rateLimitClient returns the authenticated subject or IP address of the client
+func rateLimitClient(r *http.Request) string {
+	if identity, ok := GetIdentity(r.Context()); ok {
+		return identity.Subject
+	}
+
+	return "ip:" + clientIP(r)
+}
+
+// clientIP returns IP address of the connection of the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
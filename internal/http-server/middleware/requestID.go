@@ -1,35 +1,53 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
 )
 
-type requestIDKeyType struct{}
+const (
+	requestIDHeader = "X-Request-ID"
 
-var requestIDKey requestIDKeyType
+	// maxRequestIDLength limits length of request ID accepted from the client
+	maxRequestIDLength = 128
+)
 
+// NewRequestIDMiddleware takes request ID from X-Request-ID header set by upstream service
+// or generates a new one if the header is missing or malformed
 func NewRequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := uuid.New().String()
+		requestID := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
 
-		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx := reqctx.WithRequestID(r.Context(), requestID)
 		r = r.WithContext(ctx)
 
-		w.Header().Set("X-Request-ID", requestID)
+		w.Header().Set(requestIDHeader, requestID)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
-func GetRequestID(ctx context.Context) string {
-	if v := ctx.Value(requestIDKey); v != nil {
-		if id, ok := v.(string); ok {
-			return id
+// isValidRequestID reports whether id is not empty, not longer than maxRequestIDLength
+// and contains only ASCII letters, digits and ._:- characters,
+// so that it is safe to put into logs, headers and SQL comments
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == ':', c == '-':
+		default:
+			return false
 		}
 	}
 
-	return ""
+	return true
 }
@@ -0,0 +1,191 @@
+// Package jwt verifies HS256 and RS256 signed JSON Web Tokens issued to users of the frontend
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	// leeway tolerates clock skew between the issuer and the service
+	leeway = 30 * time.Second
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are registered claims of the token and the role of staff users
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  Audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	Role      string   `json:"role"`
+}
+
+// Audience is aud claim, which is either a string or an array of strings
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verifier checks token signature with the HMAC secret or RSA keys and validates its claims.
+// Tokens are accepted only with algorithms having a configured key.
+type Verifier struct {
+	secret  []byte
+	rsaKeys map[string]*rsa.PublicKey
+
+	issuer   string
+	audience string
+}
+
+// NewVerifier creates verifier of HS256 tokens signed with secret and RS256 tokens signed with rsaKeys by key ID.
+// Empty issuer and audience are not checked.
+func NewVerifier(secret []byte, rsaKeys map[string]*rsa.PublicKey, issuer, audience string) *Verifier {
+	return &Verifier{
+		secret:   secret,
+		rsaKeys:  rsaKeys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// Verify returns claims of a valid token, errors wrap ErrInvalidToken
+func (v *Verifier) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	signed := parts[0] + "." + parts[1]
+
+	if err := v.verifySignature(h, signed, signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	if err := v.validateClaims(&claims, now); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (v *Verifier) verifySignature(h header, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch h.Alg {
+	case AlgHS256:
+		if len(v.secret) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	case AlgRS256:
+		if len(v.rsaKeys) == 0 {
+			break
+		}
+		// tokens without key ID are checked against every key
+		for kid, key := range v.rsaKeys {
+			if h.Kid != "" && h.Kid != kid {
+				continue
+			}
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Alg)
+}
+
+func (v *Verifier) validateClaims(claims *Claims, now time.Time) error {
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: subject is required", ErrInvalidToken)
+	}
+
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: expiration time is required", ErrInvalidToken)
+	}
+
+	if now.Add(-leeway).After(numericDate(*claims.ExpiresAt)) {
+		return fmt.Errorf("%w: token is expired", ErrInvalidToken)
+	}
+
+	if claims.NotBefore != nil && now.Add(leeway).Before(numericDate(*claims.NotBefore)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,54 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+// Actor is the author of purges in subscription history
+const Actor = "purge"
+
+type Repository interface {
+	PurgeDeletedSubscriptions(ctx context.Context, retention time.Duration) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// Run periodically deletes expired idempotency keys and hard deletes subscriptions soft deleted
+// more than retention ago until ctx is done, 0 retention keeps deleted subscriptions
+func Run(ctx context.Context, log *slog.Logger, repo Repository, interval, retention time.Duration) {
+	const op = "purge.Run"
+
+	log = log.With(slog.String("op", op))
+
+	ctx = reqctx.WithActor(ctx, Actor)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if retention > 0 {
+			purged, err := repo.PurgeDeletedSubscriptions(ctx, retention)
+			if err != nil && ctx.Err() == nil {
+				log.Error("failed to purge deleted subscriptions", "error", err)
+			} else if purged > 0 {
+				log.Info("deleted subscriptions purged", "count", purged)
+			}
+		}
+
+		deleted, err := repo.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to delete expired idempotency keys", "error", err)
+		} else if deleted > 0 {
+			log.Info("expired idempotency keys deleted", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
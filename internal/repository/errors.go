@@ -3,5 +3,7 @@ package repository
 import "errors"
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound        = errors.New("not found")
+	ErrVersionMismatch = errors.New("version mismatch")
+	ErrBatchRolledBack = errors.New("rolled back because another operation of the batch failed")
 )
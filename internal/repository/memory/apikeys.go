@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+func (s *StorageMemory) CreateAPIKey(ctx context.Context, in domain.CreateAPIKeyInput) (*domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := domain.APIKey{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(in.Name),
+		Prefix:    in.Prefix,
+		Role:      in.Role,
+		Hash:      in.Hash,
+		CreatedAt: currentTime(),
+	}
+
+	s.apiKeys[key.ID] = key
+
+	return &key, nil
+}
+
+func (s *StorageMemory) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]domain.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b domain.APIKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (s *StorageMemory) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+
+	revokedAt := currentTime()
+	key.RevokedAt = &revokedAt
+
+	s.apiKeys[id] = key
+
+	return nil
+}
+
+func (s *StorageMemory) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			return &key, nil
+		}
+	}
+
+	return nil, repository.ErrNotFound
+}
+
+func (s *StorageMemory) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	usedAt := currentTime()
+	key.LastUsedAt = &usedAt
+
+	s.apiKeys[id] = key
+
+	return nil
+}
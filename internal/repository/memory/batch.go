@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+// ExecuteBatch executes batch items under a single lock. In atomic mode the first failed item restores
+// the state before the batch and other items fail with ErrBatchRolledBack.
+func (s *StorageMemory) ExecuteBatch(ctx context.Context, items []domain.BatchItem, atomic bool) ([]domain.BatchItemResult, error) {
+	const op = "repository.memory.ExecuteBatch"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := maps.Clone(s.subscriptions)
+	prices := maps.Clone(s.prices)
+	history := maps.Clone(s.history)
+	historySeq := s.historySeq
+
+	rollback := func() {
+		s.subscriptions = subscriptions
+		s.prices = prices
+		s.history = history
+		s.historySeq = historySeq
+	}
+
+	results := make([]domain.BatchItemResult, len(items))
+
+	for i, item := range items {
+		sub, err := s.executeBatchItem(ctx, item)
+
+		if err != nil && !isBatchItemError(err) {
+			rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err != nil && atomic {
+			rollback()
+
+			for j := range results {
+				results[j] = domain.BatchItemResult{Err: repository.ErrBatchRolledBack}
+			}
+			results[i].Err = err
+
+			return results, nil
+		}
+
+		results[i] = domain.BatchItemResult{Subscription: sub, Err: err}
+	}
+
+	return results, nil
+}
+
+// executeBatchItem executes the item, s.mu must be held
+func (s *StorageMemory) executeBatchItem(ctx context.Context, item domain.BatchItem) (*domain.Subscription, error) {
+	switch item.Op {
+	case domain.BatchOpCreate:
+		return s.createSubscription(ctx, item.Create)
+	case domain.BatchOpUpdate:
+		return s.updateSubscription(ctx, item.ID, item.Update)
+	case domain.BatchOpDelete:
+		return nil, s.deleteSubscription(ctx, item.ID)
+	default:
+		return nil, fmt.Errorf("unknown batch operation %q", item.Op)
+	}
+}
+
+// isBatchItemError reports whether error is caused by the item itself rather than the storage
+func isBatchItemError(err error) bool {
+	var errs domain.ValidationErrors
+	return errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionMismatch) || errors.As(err, &errs)
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+func (s *StorageMemory) SubscriptionsBreakdown(ctx context.Context, in domain.BreakdownFilter) (*domain.Breakdown, error) {
+	const op = "repository.memory.SubscriptionsBreakdown"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	charges := make([]domain.MonthlyCharge, 0)
+
+	for _, sub := range s.subscriptions {
+		if sub.DeletedAt != nil || sub.UserID != in.UserID {
+			continue
+		}
+
+		if in.ServiceName != nil && sub.ServiceName != *in.ServiceName {
+			continue
+		}
+
+		if !isActiveWithin(&sub, in.From, in.To) {
+			continue
+		}
+
+		// every month of subscription period clamped to [from, to] is charged with the price effective in it
+		from, to := in.From, in.To
+
+		if sub.StartDate.Time().After(from.Time()) {
+			from = sub.StartDate
+		}
+
+		if sub.EndDate != nil && sub.EndDate.Time().Before(to.Time()) {
+			to = *sub.EndDate
+		}
+
+		prices := s.prices[sub.ID]
+
+		for month := from; !month.Time().After(to.Time()); month = month.AddMonths(1) {
+			price := sub.Price
+			if len(prices) > 0 {
+				price = prices.PriceAt(month)
+			}
+
+			charges = append(charges, domain.MonthlyCharge{
+				Month:          month,
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.ServiceName,
+				Price:          price,
+				Currency:       sub.Currency,
+
+				BillingPeriod:   sub.BillingPeriod,
+				BillingInterval: sub.BillingInterval,
+			})
+		}
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(s.exchangeRates))
+	for _, rate := range s.exchangeRates {
+		rates = append(rates, rate)
+	}
+
+	breakdown, err := domain.BuildBreakdown(charges, in, domain.NewExchangeRates(rates))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return breakdown, nil
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+func (s *StorageMemory) GetSubscriptionHistory(ctx context.Context, id uuid.UUID) ([]domain.SubscriptionHistoryRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records, ok := s.history[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return slices.Clone(records), nil
+}
+
+// appendHistory records subscription change with actor and request ID of ctx, s.mu must be held
+func (s *StorageMemory) appendHistory(ctx context.Context, action string, id uuid.UUID, old, new *domain.Subscription) error {
+	oldValue, err := domain.MarshalHistoryValue(old)
+	if err != nil {
+		return err
+	}
+
+	newValue, err := domain.MarshalHistoryValue(new)
+	if err != nil {
+		return err
+	}
+
+	s.historySeq++
+
+	s.history[id] = append(s.history[id], domain.SubscriptionHistoryRecord{
+		ID:             s.historySeq,
+		SubscriptionID: id,
+		Action:         action,
+		OldValue:       oldValue,
+		NewValue:       newValue,
+		Actor:          nullableString(reqctx.GetActor(ctx)),
+		RequestID:      nullableString(reqctx.GetRequestID(ctx)),
+		CreatedAt:      currentTime(),
+	})
+
+	return nil
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
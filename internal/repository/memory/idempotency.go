@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+// idempotencyKey identifies idempotency record, keys are scoped by client subject
+type idempotencyKey struct {
+	subject string
+	key     string
+}
+
+func (s *StorageMemory) ReserveIdempotencyKey(ctx context.Context, subject, key, requestHash string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := currentTime()
+
+	if record, ok := s.idempotencyKeys[idempotencyKey{subject, key}]; ok && record.ExpiresAt.After(now) {
+		record.ResponseHeaders = slices.Clone(record.ResponseHeaders)
+		record.ResponseBody = slices.Clone(record.ResponseBody)
+		return &record, false, nil
+	}
+
+	s.idempotencyKeys[idempotencyKey{subject, key}] = domain.IdempotencyRecord{
+		Subject:     subject,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	return nil, true, nil
+}
+
+func (s *StorageMemory) CompleteIdempotencyKey(ctx context.Context, subject, key string, statusCode int, headers json.RawMessage, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.idempotencyKeys[idempotencyKey{subject, key}]
+	if !ok {
+		return nil
+	}
+
+	record.StatusCode = statusCode
+	record.ResponseHeaders = slices.Clone(headers)
+	record.ResponseBody = slices.Clone(body)
+
+	s.idempotencyKeys[idempotencyKey{subject, key}] = record
+
+	return nil
+}
+
+func (s *StorageMemory) ReleaseIdempotencyKey(ctx context.Context, subject, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.idempotencyKeys[idempotencyKey{subject, key}]; ok && record.InProgress() {
+		delete(s.idempotencyKeys, idempotencyKey{subject, key})
+	}
+
+	return nil
+}
+
+func (s *StorageMemory) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := currentTime()
+
+	var deleted int64
+
+	for k, record := range s.idempotencyKeys {
+		if !record.ExpiresAt.After(now) {
+			delete(s.idempotencyKeys, k)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,534 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+// StorageMemory is an in-memory subscriptions storage for local runs and tests.
+// It keeps the semantics of StoragePostgres, data is lost on restart.
+type StorageMemory struct {
+	mu            sync.RWMutex
+	subscriptions map[uuid.UUID]domain.Subscription
+	prices        map[uuid.UUID]domain.PriceSchedule
+	exchangeRates map[exchangeRateKey]domain.ExchangeRate
+
+	idempotencyKeys map[idempotencyKey]domain.IdempotencyRecord
+
+	history    map[uuid.UUID][]domain.SubscriptionHistoryRecord
+	historySeq int64
+
+	apiKeys map[uuid.UUID]domain.APIKey
+}
+
+type exchangeRateKey struct {
+	currency string
+	month    time.Time
+}
+
+func NewStorageMemory() *StorageMemory {
+	return &StorageMemory{
+		subscriptions: make(map[uuid.UUID]domain.Subscription),
+		prices:        make(map[uuid.UUID]domain.PriceSchedule),
+		exchangeRates: make(map[exchangeRateKey]domain.ExchangeRate),
+
+		idempotencyKeys: make(map[idempotencyKey]domain.IdempotencyRecord),
+
+		history: make(map[uuid.UUID][]domain.SubscriptionHistoryRecord),
+
+		apiKeys: make(map[uuid.UUID]domain.APIKey),
+	}
+}
+
+func (s *StorageMemory) Close() error {
+	return nil
+}
+
+func (s *StorageMemory) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *StorageMemory) ListSubscriptions(ctx context.Context, in domain.ListSubscriptionsFilter) (*domain.SubscriptionsPage, error) {
+	const op = "repository.memory.ListSubscriptions"
+
+	if !domain.IsValidSortField(in.Sort) {
+		in.Sort = domain.SortByCreatedAt
+	}
+
+	if in.Order != domain.OrderAsc {
+		in.Order = domain.OrderDesc
+	}
+
+	limit := in.Limit
+	if limit <= 0 || limit > domain.MaxListLimit {
+		limit = domain.DefaultListLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriptions := make([]domain.Subscription, 0)
+
+	for _, sub := range s.subscriptions {
+		if !matchesListFilter(&sub, in) {
+			continue
+		}
+
+		if in.Cursor != nil {
+			c, err := in.Cursor.Compare(&sub)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if (in.Order == domain.OrderAsc && c <= 0) || (in.Order != domain.OrderAsc && c >= 0) {
+				continue
+			}
+		}
+
+		subscriptions = append(subscriptions, copySubscription(sub))
+	}
+
+	slices.SortFunc(subscriptions, func(a, b domain.Subscription) int {
+		c := domain.CompareSubscriptions(&a, &b, in.Sort)
+		if in.Order != domain.OrderAsc {
+			return -c
+		}
+		return c
+	})
+
+	page := domain.SubscriptionsPage{Items: subscriptions}
+
+	if len(subscriptions) > limit {
+		page.Items = subscriptions[:limit]
+		page.NextCursor = domain.NewListCursor(&page.Items[limit-1], in.Sort, in.Order).Encode()
+	}
+
+	return &page, nil
+}
+
+func matchesListFilter(sub *domain.Subscription, in domain.ListSubscriptionsFilter) bool {
+	if sub.DeletedAt != nil && !in.IncludeDeleted {
+		return false
+	}
+
+	if in.UserID != nil && sub.UserID != *in.UserID {
+		return false
+	}
+
+	if in.ServiceName != nil && sub.ServiceName != *in.ServiceName {
+		return false
+	}
+
+	if in.ServiceNamePrefix != nil && !strings.HasPrefix(sub.ServiceName, *in.ServiceNamePrefix) {
+		return false
+	}
+
+	if in.MinPrice != nil && sub.Price < *in.MinPrice {
+		return false
+	}
+
+	if in.MaxPrice != nil && sub.Price > *in.MaxPrice {
+		return false
+	}
+
+	if in.ActiveIn != nil && !isActiveWithin(sub, *in.ActiveIn, *in.ActiveIn) {
+		return false
+	}
+
+	return true
+}
+
+// isActiveWithin reports whether subscription period overlaps [from, to]
+func isActiveWithin(sub *domain.Subscription, from, to domain.MonthYear) bool {
+	if sub.StartDate.Time().After(to.Time()) {
+		return false
+	}
+
+	return sub.EndDate == nil || !sub.EndDate.Time().Before(from.Time())
+}
+
+func (s *StorageMemory) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+
+	sub = copySubscription(sub)
+
+	return &sub, nil
+}
+
+func (s *StorageMemory) GetSubscriptionOwner(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return uuid.Nil, repository.ErrNotFound
+	}
+
+	return sub.UserID, nil
+}
+
+func (s *StorageMemory) CreateSubscription(ctx context.Context, in domain.CreateSubscriptionInput) (*domain.Subscription, error) {
+	const op = "repository.memory.CreateSubscription"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, err := s.createSubscription(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sub, nil
+}
+
+// createSubscription stores the subscription with its price and records history, s.mu must be held
+func (s *StorageMemory) createSubscription(ctx context.Context, in domain.CreateSubscriptionInput) (*domain.Subscription, error) {
+	now := currentTime()
+
+	sub := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: in.ServiceName,
+		Price:       in.Price,
+		Currency:    in.Currency,
+
+		BillingPeriod:   in.BillingPeriod,
+		BillingInterval: in.BillingInterval,
+
+		UserID:    in.UserID,
+		StartDate: in.StartDate,
+		EndDate:   copyMonthYear(in.EndDate),
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.appendHistory(ctx, domain.HistoryActionCreate, sub.ID, nil, &sub); err != nil {
+		return nil, err
+	}
+
+	s.subscriptions[sub.ID] = sub
+	s.prices[sub.ID] = domain.PriceSchedule{{Price: sub.Price, EffectiveFrom: sub.StartDate}}
+
+	sub = copySubscription(sub)
+
+	return &sub, nil
+}
+
+func (s *StorageMemory) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.memory.DeleteSubscription"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.deleteSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// deleteSubscription soft deletes the subscription and records history, s.mu must be held
+func (s *StorageMemory) deleteSubscription(ctx context.Context, id uuid.UUID) error {
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+
+	old := sub
+
+	deletedAt := currentTime()
+	sub.DeletedAt = &deletedAt
+	sub.Version++
+
+	if err := s.appendHistory(ctx, domain.HistoryActionDelete, id, &old, &sub); err != nil {
+		return err
+	}
+
+	s.subscriptions[id] = sub
+
+	return nil
+}
+
+func (s *StorageMemory) RestoreSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	const op = "repository.memory.RestoreSubscription"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.DeletedAt == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	old := sub
+
+	sub.DeletedAt = nil
+	sub.Version++
+	sub.UpdatedAt = currentTime()
+
+	if err := s.appendHistory(ctx, domain.HistoryActionRestore, id, &old, &sub); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.subscriptions[id] = sub
+
+	sub = copySubscription(sub)
+
+	return &sub, nil
+}
+
+// PurgeSubscription hard deletes subscription including soft deleted one, its history is kept
+// and the purge is recorded in it
+func (s *StorageMemory) PurgeSubscription(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.memory.PurgeSubscription"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	if err := s.appendHistory(ctx, domain.HistoryActionPurge, id, &sub, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	delete(s.subscriptions, id)
+	delete(s.prices, id)
+
+	return nil
+}
+
+// PurgeDeletedSubscriptions hard deletes subscriptions soft deleted more than retention ago
+// and records purge of each of them in history
+func (s *StorageMemory) PurgeDeletedSubscriptions(ctx context.Context, retention time.Duration) (int64, error) {
+	const op = "repository.memory.PurgeDeletedSubscriptions"
+
+	deletedBefore := currentTime().Add(-retention)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+
+	for id, sub := range s.subscriptions {
+		if sub.DeletedAt != nil && sub.DeletedAt.Before(deletedBefore) {
+			if err := s.appendHistory(ctx, domain.HistoryActionPurge, id, &sub, nil); err != nil {
+				return purged, fmt.Errorf("%s: %w", op, err)
+			}
+
+			delete(s.subscriptions, id)
+			delete(s.prices, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (s *StorageMemory) UpdateSubscription(ctx context.Context, id uuid.UUID, in domain.UpdateSubscriptionInput) (*domain.Subscription, error) {
+	const op = "repository.memory.UpdateSubscription"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, err := s.updateSubscription(ctx, id, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sub, nil
+}
+
+// updateSubscription checks expected version, writes merged fields and records history, s.mu must be held
+func (s *StorageMemory) updateSubscription(ctx context.Context, id uuid.UUID, in domain.UpdateSubscriptionInput) (*domain.Subscription, error) {
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+
+	if in.ExpectedVersion != nil && *in.ExpectedVersion != sub.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+
+	old := sub
+
+	in.Apply(&sub)
+	sub.EndDate = copyMonthYear(sub.EndDate)
+
+	if errs := sub.ValidatePeriod(); errs != nil {
+		return nil, errs
+	}
+
+	sub.Version++
+	sub.UpdatedAt = currentTime()
+
+	if err := s.appendHistory(ctx, domain.HistoryActionUpdate, id, &old, &sub); err != nil {
+		return nil, err
+	}
+
+	if schedule, ok := in.ChangePrice(s.prices[id], &old, &sub); ok {
+		s.prices[id] = schedule
+	}
+
+	s.subscriptions[id] = sub
+
+	sub = copySubscription(sub)
+
+	return &sub, nil
+}
+
+func (s *StorageMemory) SumSubscriptionsPrices(ctx context.Context, in domain.SumSubscriptionsFilter) (*domain.SubscriptionsSum, error) {
+	const op = "repository.memory.SumSubscriptionsPrices"
+
+	in.SetDefaults()
+
+	if errs := in.Validate(); errs != nil {
+		return nil, fmt.Errorf("%s: %w", op, errs)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	costs := make([]domain.SubscriptionCost, 0)
+
+	for _, sub := range s.subscriptions {
+		if sub.DeletedAt != nil {
+			continue
+		}
+
+		if len(in.UserIDs) > 0 && !slices.Contains(in.UserIDs, sub.UserID) {
+			continue
+		}
+
+		if len(in.ServiceNames) > 0 && !slices.Contains(in.ServiceNames, sub.ServiceName) {
+			continue
+		}
+
+		// subscription period is clamped to [from, to], subscriptions are billed from their start if from is not set
+		from, to := sub.StartDate, *in.To
+
+		if in.From != nil && in.From.Time().After(from.Time()) {
+			from = *in.From
+		}
+
+		if !isActiveWithin(&sub, from, to) {
+			continue
+		}
+
+		if sub.EndDate != nil && sub.EndDate.Time().Before(to.Time()) {
+			to = *sub.EndDate
+		}
+
+		costs = append(costs, domain.SubscriptionCost{
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			Price:          sub.Price,
+			Currency:       sub.Currency,
+
+			BillingPeriod:   sub.BillingPeriod,
+			BillingInterval: sub.BillingInterval,
+
+			BilledFrom: from,
+			BilledTo:   to,
+			Months:     domain.MonthsBetween(from, to),
+
+			Prices: slices.Clone(s.prices[sub.ID]),
+		})
+	}
+
+	slices.SortFunc(costs, func(a, b domain.SubscriptionCost) int {
+		sa, sb := s.subscriptions[a.SubscriptionID], s.subscriptions[b.SubscriptionID]
+		return domain.CompareSubscriptions(&sa, &sb, domain.SortByStartDate)
+	})
+
+	currency := in.Currency
+
+	rates := make([]domain.ExchangeRate, 0, len(s.exchangeRates))
+	for _, rate := range s.exchangeRates {
+		rates = append(rates, rate)
+	}
+
+	sum, err := domain.SumCosts(costs, currency, domain.NewExchangeRates(rates))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sum, nil
+}
+
+func (s *StorageMemory) ListExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := make([]domain.ExchangeRate, 0, len(s.exchangeRates))
+	for _, rate := range s.exchangeRates {
+		rates = append(rates, rate)
+	}
+
+	slices.SortFunc(rates, func(a, b domain.ExchangeRate) int {
+		if c := strings.Compare(a.Currency, b.Currency); c != 0 {
+			return c
+		}
+		return a.Month.Time().Compare(b.Month.Time())
+	})
+
+	return rates, nil
+}
+
+func (s *StorageMemory) UpsertExchangeRates(ctx context.Context, rates []domain.ExchangeRate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rate := range rates {
+		s.exchangeRates[exchangeRateKey{currency: rate.Currency, month: rate.Month.Time()}] = rate
+	}
+
+	return nil
+}
+
+// currentTime returns current time with database timestamp precision
+func currentTime() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func copySubscription(sub domain.Subscription) domain.Subscription {
+	sub.EndDate = copyMonthYear(sub.EndDate)
+
+	if sub.DeletedAt != nil {
+		deletedAt := *sub.DeletedAt
+		sub.DeletedAt = &deletedAt
+	}
+
+	return sub
+}
+
+func copyMonthYear(my *domain.MonthYear) *domain.MonthYear {
+	if my == nil {
+		return nil
+	}
+
+	v := *my
+
+	return &v
+}
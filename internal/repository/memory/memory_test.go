@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+var testUserID = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+func month(t *testing.T, s string) domain.MonthYear {
+	t.Helper()
+
+	my, err := domain.ParseMonthYear(s)
+	if err != nil {
+		t.Fatalf("parse month %q: %v", s, err)
+	}
+
+	return my
+}
+
+func monthPtr(t *testing.T, s string) *domain.MonthYear {
+	t.Helper()
+
+	if s == "" {
+		return nil
+	}
+
+	my := month(t, s)
+	return &my
+}
+
+// createSubscription creates monthly subscription of testUserID, empty end is an open-ended period
+func createSubscription(t *testing.T, s *StorageMemory, name string, price int, start, end string) *domain.Subscription {
+	t.Helper()
+
+	in := domain.CreateSubscriptionInput{
+		ServiceName: name,
+		Price:       price,
+		UserID:      testUserID,
+		StartDate:   month(t, start),
+		EndDate:     monthPtr(t, end),
+	}
+	in.SetDefaults()
+
+	sub, err := s.CreateSubscription(context.Background(), in)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	return sub
+}
+
+func TestCreateAndGetSubscription(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorageMemory()
+
+	created := createSubscription(t, s, "Netflix", 499, "07-2025", "12-2025")
+
+	deleted := createSubscription(t, s, "Spotify", 299, "07-2025", "")
+	if err := s.DeleteSubscription(ctx, deleted.ID); err != nil {
+		t.Fatalf("delete subscription: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		id      uuid.UUID
+		wantErr error
+	}{
+		{"existing", created.ID, nil},
+		{"unknown", uuid.New(), repository.ErrNotFound},
+		{"soft deleted", deleted.ID, repository.ErrNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.GetSubscriptionByID(ctx, tc.id)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			if got.ID != created.ID || got.ServiceName != "Netflix" || got.Price != 499 || got.UserID != testUserID {
+				t.Errorf("got %+v, want %+v", got, created)
+			}
+			if got.Currency != domain.DefaultCurrency || got.BillingPeriod != domain.BillingPeriodMonthly || got.Version != 1 {
+				t.Errorf("got currency %s, billing period %s, version %d, want defaults and version 1",
+					got.Currency, got.BillingPeriod, got.Version)
+			}
+			if got.EndDate == nil || !got.EndDate.Time().Equal(month(t, "12-2025").Time()) {
+				t.Errorf("got end date %v, want 12-2025", got.EndDate)
+			}
+		})
+	}
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorageMemory()
+
+	id := uuid.New()
+	price := 100
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+	}{
+		{"update", func() error {
+			_, err := s.UpdateSubscription(ctx, id, domain.UpdateSubscriptionInput{Price: &price})
+			return err
+		}},
+		{"delete", func() error { return s.DeleteSubscription(ctx, id) }},
+		{"restore", func() error {
+			_, err := s.RestoreSubscription(ctx, id)
+			return err
+		}},
+		{"purge", func() error { return s.PurgeSubscription(ctx, id) }},
+		{"history", func() error {
+			_, err := s.GetSubscriptionHistory(ctx, id)
+			return err
+		}},
+		{"owner", func() error {
+			_, err := s.GetSubscriptionOwner(ctx, id)
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.call(); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("got error %v, want %v", err, repository.ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestListSubscriptionsActiveIn(t *testing.T) {
+	s := NewStorageMemory()
+
+	// periods are inclusive on both ends, subscriptions without end date are active since start
+	createSubscription(t, s, "closed", 100, "03-2025", "06-2025")
+	createSubscription(t, s, "single", 100, "05-2025", "05-2025")
+	createSubscription(t, s, "open", 100, "06-2025", "")
+
+	for _, tc := range []struct {
+		activeIn string
+		want     []string
+	}{
+		{"02-2025", nil},
+		{"03-2025", []string{"closed"}},
+		{"05-2025", []string{"closed", "single"}},
+		{"06-2025", []string{"closed", "open"}},
+		{"07-2025", []string{"open"}},
+		{"01-2030", []string{"open"}},
+	} {
+		t.Run(tc.activeIn, func(t *testing.T) {
+			page, err := s.ListSubscriptions(context.Background(), domain.ListSubscriptionsFilter{
+				ActiveIn: monthPtr(t, tc.activeIn),
+				Sort:     domain.SortByStartDate,
+				Order:    domain.OrderAsc,
+			})
+			if err != nil {
+				t.Fatalf("list subscriptions: %v", err)
+			}
+
+			var got []string
+			for _, sub := range page.Items {
+				got = append(got, sub.ServiceName)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSumSubscriptionsPricesPeriod(t *testing.T) {
+	s := NewStorageMemory()
+
+	createSubscription(t, s, "closed", 100, "03-2025", "06-2025")
+	createSubscription(t, s, "open", 10, "05-2025", "")
+
+	for _, tc := range []struct {
+		name     string
+		from, to string
+		// months billed per subscription, missing subscriptions are outside the period
+		wantMonths map[string]int
+		wantAmount int
+	}{
+		{
+			name: "without from subscriptions are billed from start", to: "12-2025",
+			wantMonths: map[string]int{"closed": 4, "open": 8}, wantAmount: 480,
+		},
+		{
+			name: "period inside subscriptions", from: "04-2025", to: "05-2025",
+			wantMonths: map[string]int{"closed": 2, "open": 1}, wantAmount: 210,
+		},
+		{
+			name: "period boundaries are inclusive", from: "06-2025", to: "06-2025",
+			wantMonths: map[string]int{"closed": 1, "open": 1}, wantAmount: 110,
+		},
+		{
+			name: "subscription ended before from", from: "07-2025", to: "08-2025",
+			wantMonths: map[string]int{"open": 2}, wantAmount: 20,
+		},
+		{
+			name: "period before all subscriptions", from: "01-2025", to: "02-2025",
+			wantMonths: map[string]int{}, wantAmount: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sum, err := s.SumSubscriptionsPrices(context.Background(), domain.SumSubscriptionsFilter{
+				From: monthPtr(t, tc.from),
+				To:   monthPtr(t, tc.to),
+			})
+			if err != nil {
+				t.Fatalf("sum subscriptions prices: %v", err)
+			}
+
+			got := make(map[string]int, len(sum.Subscriptions))
+			for _, cost := range sum.Subscriptions {
+				got[cost.ServiceName] = cost.Months
+			}
+
+			if len(got) != len(tc.wantMonths) {
+				t.Errorf("got months %v, want %v", got, tc.wantMonths)
+			}
+			for name, months := range tc.wantMonths {
+				if got[name] != months {
+					t.Errorf("%s: got %d months, want %d", name, got[name], months)
+				}
+			}
+
+			if sum.Amount != tc.wantAmount {
+				t.Errorf("got amount %d, want %d", sum.Amount, tc.wantAmount)
+			}
+		})
+	}
+}
+
+func TestSumSubscriptionsPricesInvalidPeriod(t *testing.T) {
+	s := NewStorageMemory()
+
+	_, err := s.SumSubscriptionsPrices(context.Background(), domain.SumSubscriptionsFilter{
+		From: monthPtr(t, "06-2025"),
+		To:   monthPtr(t, "05-2025"),
+	})
+
+	var errs domain.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("got error %v, want validation errors", err)
+	}
+}
+
+func TestSubscriptionsBreakdownPeriod(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorageMemory()
+
+	closed := createSubscription(t, s, "closed", 100, "03-2025", "06-2025")
+	createSubscription(t, s, "open", 10, "05-2025", "")
+
+	// the price changes from 05-2025, earlier months keep the old price
+	price, from := 200, month(t, "05-2025")
+	if _, err := s.UpdateSubscription(ctx, closed.ID, domain.UpdateSubscriptionInput{Price: &price, PriceEffectiveFrom: &from}); err != nil {
+		t.Fatalf("update subscription: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from, to string
+		groupBy  string
+		want     []domain.BreakdownBucket
+	}{
+		{
+			name: "months of the period are kept without charges", from: "02-2025", to: "08-2025", groupBy: domain.BreakdownGroupByMonth,
+			want: []domain.BreakdownBucket{
+				{Month: month(t, "02-2025"), Amount: 0},
+				{Month: month(t, "03-2025"), Amount: 100},
+				{Month: month(t, "04-2025"), Amount: 100},
+				{Month: month(t, "05-2025"), Amount: 210},
+				{Month: month(t, "06-2025"), Amount: 210},
+				{Month: month(t, "07-2025"), Amount: 10},
+				{Month: month(t, "08-2025"), Amount: 10},
+			},
+		},
+		{
+			name: "services within months", from: "06-2025", to: "07-2025", groupBy: domain.BreakdownGroupByService,
+			want: []domain.BreakdownBucket{
+				{Month: month(t, "06-2025"), ServiceName: "closed", Amount: 200},
+				{Month: month(t, "06-2025"), ServiceName: "open", Amount: 10},
+				{Month: month(t, "07-2025"), ServiceName: "open", Amount: 10},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			breakdown, err := s.SubscriptionsBreakdown(ctx, domain.BreakdownFilter{
+				UserID:   testUserID,
+				From:     month(t, tc.from),
+				To:       month(t, tc.to),
+				GroupBy:  tc.groupBy,
+				Currency: domain.DefaultCurrency,
+			})
+			if err != nil {
+				t.Fatalf("subscriptions breakdown: %v", err)
+			}
+
+			if len(breakdown.Buckets) != len(tc.want) {
+				t.Fatalf("got %d buckets, want %d: %+v", len(breakdown.Buckets), len(tc.want), breakdown.Buckets)
+			}
+
+			for i, want := range tc.want {
+				got := breakdown.Buckets[i]
+				if !got.Month.Time().Equal(want.Month.Time()) || got.ServiceName != want.ServiceName || got.Amount != want.Amount {
+					t.Errorf("bucket %d: got %s %q %d, want %s %q %d", i,
+						got.Month.Time().Format(domain.MonthYearLayout), got.ServiceName, got.Amount,
+						want.Month.Time().Format(domain.MonthYearLayout), want.ServiceName, want.Amount)
+				}
+			}
+		})
+	}
+}
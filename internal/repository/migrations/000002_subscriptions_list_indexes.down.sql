@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_created_at_id;
@@ -0,0 +1,5 @@
+CREATE INDEX idx_subscriptions_created_at_id
+    ON subscriptions (created_at, id);
+
+CREATE INDEX idx_subscriptions_user_id_created_at_id
+    ON subscriptions (user_id, created_at, id);
@@ -0,0 +1,10 @@
+ALTER TABLE subscriptions
+    ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB'
+        CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE TABLE exchange_rates (
+    currency TEXT           NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    month    DATE           NOT NULL,
+    rate     NUMERIC(20, 8) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (currency, month)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+    key           TEXT      PRIMARY KEY,
+    request_hash  TEXT      NOT NULL,
+    status_code   INTEGER,
+    response_body BYTEA,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at
+    ON idempotency_keys (expires_at);
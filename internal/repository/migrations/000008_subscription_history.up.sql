@@ -0,0 +1,13 @@
+CREATE TABLE subscription_history (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id UUID      NOT NULL,
+    action          TEXT      NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    old_value       JSONB,
+    new_value       JSONB,
+    actor           TEXT,
+    request_id      TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscription_history_subscription_id
+    ON subscription_history (subscription_id, id);
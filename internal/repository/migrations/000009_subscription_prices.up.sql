@@ -0,0 +1,10 @@
+CREATE TABLE subscription_prices (
+    subscription_id UUID    NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from  DATE    NOT NULL,
+    price           INTEGER NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+INSERT INTO subscription_prices (subscription_id, effective_from, price)
+SELECT id, start_date, price
+FROM subscriptions;
@@ -0,0 +1,10 @@
+CREATE TABLE api_keys (
+    id           UUID      PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    admin        BOOLEAN   NOT NULL DEFAULT false,
+    key_hash     TEXT      NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+);
@@ -0,0 +1,7 @@
+ALTER TABLE api_keys
+    ADD COLUMN admin BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE api_keys SET admin = true WHERE role = 'admin';
+
+ALTER TABLE api_keys
+    DROP COLUMN role;
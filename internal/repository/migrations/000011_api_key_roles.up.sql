@@ -0,0 +1,7 @@
+ALTER TABLE api_keys
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'editor' CHECK (role IN ('viewer', 'editor', 'admin'));
+
+UPDATE api_keys SET role = 'admin' WHERE admin;
+
+ALTER TABLE api_keys
+    DROP COLUMN admin;
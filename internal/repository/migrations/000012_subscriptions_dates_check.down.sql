@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions
+    DROP CONSTRAINT subscriptions_dates_check;
@@ -0,0 +1,4 @@
+-- existing rows are not checked, so that the migration does not fail on data written before the constraint;
+-- after fixing them the constraint can be checked with VALIDATE CONSTRAINT
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_dates_check CHECK (end_date IS NULL OR end_date >= start_date) NOT VALID;
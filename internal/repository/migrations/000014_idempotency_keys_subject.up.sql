@@ -0,0 +1,11 @@
+-- keys are scoped by the authenticated client, so that clients choosing the same key do not see each other's responses
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys
+    ADD COLUMN subject TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT idempotency_keys_pkey;
+
+ALTER TABLE idempotency_keys
+    ADD PRIMARY KEY (subject, key);
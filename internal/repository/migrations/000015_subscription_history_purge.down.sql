@@ -0,0 +1,9 @@
+DELETE FROM subscription_history
+WHERE action = 'purge';
+
+ALTER TABLE subscription_history
+    DROP CONSTRAINT subscription_history_action_check;
+
+ALTER TABLE subscription_history
+    ADD CONSTRAINT subscription_history_action_check
+        CHECK (action IN ('create', 'update', 'delete', 'restore'));
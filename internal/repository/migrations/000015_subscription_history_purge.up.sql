@@ -0,0 +1,7 @@
+-- purges are recorded in history, so that hard deleted subscriptions keep their last state
+ALTER TABLE subscription_history
+    DROP CONSTRAINT subscription_history_action_check;
+
+ALTER TABLE subscription_history
+    ADD CONSTRAINT subscription_history_action_check
+        CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge'));
@@ -0,0 +1,10 @@
+// Package migrations contains SQL schema migrations embedded into the binary.
+//
+// Files are named {version}_{title}.up.sql and {version}_{title}.down.sql,
+// the same layout golang-migrate uses, so they can be applied by either tool.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+const apiKeyColumns = "id, name, prefix, role, key_hash, created_at, last_used_at, revoked_at"
+
+func (s *StoragePostgres) CreateAPIKey(ctx context.Context, in domain.CreateAPIKeyInput) (_ *domain.APIKey, err error) {
+	const op = "repository.postgres.CreateAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	var key domain.APIKey
+
+	query := `
+		INSERT INTO api_keys (name, prefix, role, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + apiKeyColumns + `;
+	`
+
+	err = s.db.GetContext(ctx, &key, tagQuery(ctx, query), strings.TrimSpace(in.Name), in.Prefix, in.Role, in.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+func (s *StoragePostgres) ListAPIKeys(ctx context.Context) (_ []domain.APIKey, err error) {
+	const op = "repository.postgres.ListAPIKeys"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	keys := make([]domain.APIKey, 0)
+
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at, id;
+	`
+
+	err = s.db.SelectContext(ctx, &keys, tagQuery(ctx, query))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key as revoked, revoked keys are kept to show their usage
+func (s *StoragePostgres) RevokeAPIKey(ctx context.Context, id uuid.UUID) (err error) {
+	const op = "repository.postgres.RevokeAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	query := `
+		UPDATE api_keys
+		SET revoked_at = now()
+		WHERE id = $1 AND revoked_at IS NULL;
+	`
+
+	result, err := s.db.ExecContext(ctx, tagQuery(ctx, query), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revoked == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash returns not revoked key with the token hash
+func (s *StoragePostgres) GetAPIKeyByHash(ctx context.Context, hash string) (_ *domain.APIKey, err error) {
+	const op = "repository.postgres.GetAPIKeyByHash"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	var key domain.APIKey
+
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL;
+	`
+
+	err = s.db.GetContext(ctx, &key, tagQuery(ctx, query), hash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// TouchAPIKey sets last usage time of the key to now
+func (s *StoragePostgres) TouchAPIKey(ctx context.Context, id uuid.UUID) (err error) {
+	const op = "repository.postgres.TouchAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	query := `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1;
+	`
+
+	_, err = s.db.ExecContext(ctx, tagQuery(ctx, query), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
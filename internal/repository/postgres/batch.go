@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+)
+
+// ExecuteBatch executes batch items in a single transaction. In atomic mode the first failed item rolls back
+// the whole batch and other items fail with ErrBatchRolledBack, otherwise failed items are rolled back to savepoints.
+func (s *StoragePostgres) ExecuteBatch(ctx context.Context, items []domain.BatchItem, atomic bool) (_ []domain.BatchItemResult, err error) {
+	const op = "repository.postgres.ExecuteBatch"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	results := make([]domain.BatchItemResult, len(items))
+
+	for i, item := range items {
+		if !atomic {
+			_, err = tx.ExecContext(ctx, "SAVEPOINT batch_item;")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		sub, err := executeBatchItem(ctx, tx, item)
+
+		if err != nil && !isBatchItemError(err) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err != nil && atomic {
+			for j := range results {
+				results[j] = domain.BatchItemResult{Err: repository.ErrBatchRolledBack}
+			}
+			results[i].Err = err
+
+			return results, nil
+		}
+
+		if err != nil {
+			_, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item;")
+			if rollbackErr != nil {
+				return nil, fmt.Errorf("%s: %w", op, rollbackErr)
+			}
+
+			results[i].Err = err
+			continue
+		}
+
+		if !atomic {
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item;")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		results[i].Subscription = sub
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func executeBatchItem(ctx context.Context, tx *sqlx.Tx, item domain.BatchItem) (*domain.Subscription, error) {
+	switch item.Op {
+	case domain.BatchOpCreate:
+		return createSubscription(ctx, tx, item.Create)
+	case domain.BatchOpUpdate:
+		return updateSubscription(ctx, tx, item.ID, item.Update)
+	case domain.BatchOpDelete:
+		return nil, deleteSubscription(ctx, tx, item.ID)
+	default:
+		return nil, fmt.Errorf("unknown batch operation %q", item.Op)
+	}
+}
+
+// isBatchItemError reports whether error is caused by the item itself rather than the storage
+func isBatchItemError(err error) bool {
+	var errs domain.ValidationErrors
+	return errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionMismatch) || errors.As(err, &errs)
+}
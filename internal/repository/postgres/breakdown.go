@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+func (s *StoragePostgres) SubscriptionsBreakdown(ctx context.Context, in domain.BreakdownFilter) (_ *domain.Breakdown, err error) {
+	const op = "repository.postgres.SubscriptionsBreakdown"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	charges := make([]domain.MonthlyCharge, 0)
+
+	// every month of subscription period clamped to [from, to] is charged with the price effective in it,
+	// the first price is used for months before the first price segment
+	query := `
+		SELECT month::date AS month, s.id, s.service_name, s.currency, s.billing_period, s.billing_interval,
+		       COALESCE(
+		           (SELECT p.price FROM subscription_prices p
+		            WHERE p.subscription_id = s.id AND p.effective_from <= month
+		            ORDER BY p.effective_from DESC LIMIT 1),
+		           (SELECT p.price FROM subscription_prices p
+		            WHERE p.subscription_id = s.id
+		            ORDER BY p.effective_from LIMIT 1),
+		           s.price
+		       ) AS price
+		FROM subscriptions s
+		CROSS JOIN LATERAL generate_series(
+		    GREATEST(s.start_date, $2::date)::timestamp,
+		    LEAST(COALESCE(s.end_date, $3::date), $3::date)::timestamp,
+		    interval '1 month'
+		) AS month
+		WHERE s.user_id = $1
+		  AND ($4::text IS NULL OR s.service_name = $4)
+		  AND s.deleted_at IS NULL
+		  AND s.start_date <= $3::date
+		  AND (s.end_date IS NULL OR s.end_date >= $2::date)
+		ORDER BY month, s.service_name, s.id;
+	`
+
+	from := in.From.MonthYearPtrToTimePtr()
+	to := in.To.MonthYearPtrToTimePtr()
+
+	err = s.db.SelectContext(ctx, &charges, tagQuery(ctx, query), in.UserID, from, to, in.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rates := domain.NewExchangeRates(nil)
+
+	for _, charge := range charges {
+		if charge.Currency != in.Currency {
+			loaded, err := s.ListExchangeRates(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			rates = domain.NewExchangeRates(loaded)
+			break
+		}
+	}
+
+	breakdown, err := domain.BuildBreakdown(charges, in, rates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return breakdown, nil
+}
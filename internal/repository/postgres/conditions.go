@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryConditions builds WHERE clause of a query with positional arguments
+type queryConditions struct {
+	conditions []string
+	args       []any
+}
+
+// arg adds query argument and returns its placeholder
+func (c *queryConditions) arg(value any) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+// add adds condition with placeholders of the values substituted into format
+func (c *queryConditions) add(format string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, v := range values {
+		placeholders[i] = c.arg(v)
+	}
+	c.conditions = append(c.conditions, fmt.Sprintf(format, placeholders...))
+}
+
+// where returns WHERE clause of the conditions, empty if there are none
+func (c *queryConditions) where() string {
+	if len(c.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.conditions, " AND ") + "\n"
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+)
+
+func (s *StoragePostgres) GetSubscriptionHistory(ctx context.Context, id uuid.UUID) (_ []domain.SubscriptionHistoryRecord, err error) {
+	const op = "repository.postgres.GetSubscriptionHistory"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	records := make([]domain.SubscriptionHistoryRecord, 0)
+
+	query := `
+		SELECT id, subscription_id, action, old_value, new_value, actor, request_id, created_at
+		FROM subscription_history
+		WHERE subscription_id = $1
+		ORDER BY id;
+	`
+
+	err = s.db.SelectContext(ctx, &records, tagQuery(ctx, query), id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(records) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	return records, nil
+}
+
+// insertHistory appends subscription change record with actor and request ID of ctx within the transaction
+func insertHistory(ctx context.Context, tx *sqlx.Tx, action string, id uuid.UUID, old, new *domain.Subscription) error {
+	oldValue, err := domain.MarshalHistoryValue(old)
+	if err != nil {
+		return err
+	}
+
+	newValue, err := domain.MarshalHistoryValue(new)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_history (subscription_id, action, old_value, new_value, actor, request_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''));
+	`
+
+	_, err = tx.ExecContext(
+		ctx, tagQuery(ctx, query), id, action, nullableJSON(oldValue), nullableJSON(newValue),
+		reqctx.GetActor(ctx), reqctx.GetRequestID(ctx),
+	)
+
+	return err
+}
+
+// nullableJSON converts empty JSON value to NULL query argument
+func nullableJSON(v []byte) any {
+	if v == nil {
+		return nil
+	}
+
+	return string(v)
+}
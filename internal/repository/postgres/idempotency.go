@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+)
+
+// reserveIdempotencyKeyAttempts limits retries of reservation of a key released between the insert and the select
+const reserveIdempotencyKeyAttempts = 3
+
+// ReserveIdempotencyKey stores in-progress record of the subject's key unless a not expired record exists.
+// Returns the existing record and false if the key is already taken.
+func (s *StoragePostgres) ReserveIdempotencyKey(ctx context.Context, subject, key, requestHash string, ttl time.Duration) (_ *domain.IdempotencyRecord, _ bool, err error) {
+	const op = "repository.postgres.ReserveIdempotencyKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	for attempt := 1; ; attempt++ {
+		record, reserved, err := s.reserveIdempotencyKey(ctx, subject, key, requestHash, ttl)
+		if errors.Is(err, sql.ErrNoRows) && attempt < reserveIdempotencyKeyAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return record, reserved, nil
+	}
+}
+
+// reserveIdempotencyKey makes a single reservation attempt. It returns sql.ErrNoRows
+// if the existing record was released or replaced after the insert conflicted with it.
+func (s *StoragePostgres) reserveIdempotencyKey(ctx context.Context, subject, key, requestHash string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	var reserved string
+
+	// expired record is replaced as if it did not exist
+	query := `
+		INSERT INTO idempotency_keys (subject, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (subject, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_headers = NULL, response_body = NULL,
+		    created_at = now(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		RETURNING key;
+	`
+
+	err := s.db.GetContext(ctx, &reserved, tagQuery(ctx, query), subject, key, requestHash, ttl.Seconds())
+	if err == nil {
+		return nil, true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	var record domain.IdempotencyRecord
+
+	query = `
+		SELECT subject, key, request_hash, COALESCE(status_code, 0) AS status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE subject = $1 AND key = $2;
+	`
+
+	err = s.db.GetContext(ctx, &record, tagQuery(ctx, query), subject, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &record, false, nil
+}
+
+// CompleteIdempotencyKey stores response of the request made with the subject's key
+func (s *StoragePostgres) CompleteIdempotencyKey(ctx context.Context, subject, key string, statusCode int, headers json.RawMessage, body []byte) (err error) {
+	const op = "repository.postgres.CompleteIdempotencyKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_headers = $4, response_body = $5
+		WHERE subject = $1 AND key = $2;
+	`
+
+	_, err = s.db.ExecContext(ctx, tagQuery(ctx, query), subject, key, statusCode, nullableJSON(headers), body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey removes in-progress record of the subject's key, so the request can be retried
+func (s *StoragePostgres) ReleaseIdempotencyKey(ctx context.Context, subject, key string) (err error) {
+	const op = "repository.postgres.ReleaseIdempotencyKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE subject = $1 AND key = $2 AND status_code IS NULL;
+	`
+
+	_, err = s.db.ExecContext(ctx, tagQuery(ctx, query), subject, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes records of all subjects whose replay period is over
+func (s *StoragePostgres) DeleteExpiredIdempotencyKeys(ctx context.Context) (_ int64, err error) {
+	const op = "repository.postgres.DeleteExpiredIdempotencyKeys"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= now();
+	`
+
+	result, err := s.db.ExecContext(ctx, tagQuery(ctx, query))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,342 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/l-golofastov/subscriptions-manager/internal/repository/migrations"
+)
+
+// migrationsLockID is the key of advisory lock held while migrations are applied
+const migrationsLockID = 727_390_241
+
+var ErrDirtyMigration = errors.New("database is in dirty migration state, fix it manually")
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a single schema migration
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus represents state of schema migrations in database
+type MigrationStatus struct {
+	// Version is the last applied migration version, 0 if none applied
+	Version uint
+	Dirty   bool
+	Pending []Migration
+}
+
+// loadMigrations reads embedded migrations sorted by version
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(migrations.FS, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		result = append(result, *m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// MigrateUp applies all pending migrations and returns the number of applied ones
+func (s *StoragePostgres) MigrateUp(ctx context.Context) (int, error) {
+	const op = "repository.postgres.MigrateUp"
+
+	all, err := loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied := 0
+
+	err = s.withMigrationsLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := migrationVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return ErrDirtyMigration
+		}
+
+		for _, m := range all {
+			if m.Version <= version {
+				continue
+			}
+
+			if err := applyMigration(ctx, conn, m.Up, m.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return applied, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown rolls back the last applied migration and returns the resulting version
+func (s *StoragePostgres) MigrateDown(ctx context.Context) (uint, error) {
+	const op = "repository.postgres.MigrateDown"
+
+	all, err := loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var result uint
+
+	err = s.withMigrationsLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := migrationVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return ErrDirtyMigration
+		}
+
+		result = version
+
+		if version == 0 {
+			return nil
+		}
+
+		var previous uint
+
+		for i, m := range all {
+			if m.Version != version {
+				continue
+			}
+
+			if i > 0 {
+				previous = all[i-1].Version
+			}
+
+			if err := applyMigration(ctx, conn, m.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+
+			result = previous
+
+			return nil
+		}
+
+		return fmt.Errorf("applied migration %d not found", version)
+	})
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// MigrationStatus returns the current schema version and pending migrations
+func (s *StoragePostgres) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	const op = "repository.postgres.MigrationStatus"
+
+	all, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	version, dirty, err := migrationVersion(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := MigrationStatus{Version: version, Dirty: dirty, Pending: make([]Migration, 0)}
+
+	for _, m := range all {
+		if m.Version > version {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+
+	return &status, nil
+}
+
+// SchemaVersion returns the last applied migration version and whether it is dirty
+func (s *StoragePostgres) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	const op = "repository.postgres.SchemaVersion"
+
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	version, dirty, err := migrationVersion(ctx, conn)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, dirty, nil
+}
+
+// withMigrationsLock runs fn on a single connection holding migrations advisory lock,
+// so that several application instances starting at once do not apply migrations concurrently
+func (s *StoragePostgres) withMigrationsLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, migrationsLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1);`, migrationsLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// ensureMigrationsTable creates versions table in golang-migrate format if it does not exist
+func ensureMigrationsTable(ctx context.Context, conn *sqlx.Conn) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT  NOT NULL PRIMARY KEY,
+			dirty   BOOLEAN NOT NULL
+		);
+	`
+
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+func migrationVersion(ctx context.Context, conn *sqlx.Conn) (uint, bool, error) {
+	var exists bool
+
+	err := conn.GetContext(ctx, &exists, `SELECT to_regclass('schema_migrations') IS NOT NULL;`)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	var rows []struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+
+	err = conn.SelectContext(ctx, &rows, `SELECT version, dirty FROM schema_migrations LIMIT 1;`)
+	if err != nil || len(rows) == 0 {
+		return 0, false, err
+	}
+
+	return uint(rows[0].Version), rows[0].Dirty, nil
+}
+
+// applyMigration marks schema dirty at version, then executes migration body and clears the mark
+// in one transaction, so that an interrupted or failed migration leaves the schema dirty as golang-migrate does
+func applyMigration(ctx context.Context, conn *sqlx.Conn, body string, version uint) error {
+	if err := setMigrationVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+
+	if err := setMigrationVersionTx(ctx, tx, version, false); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setMigrationVersion replaces the stored schema version in its own transaction
+func setMigrationVersion(ctx context.Context, conn *sqlx.Conn, version uint, dirty bool) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setMigrationVersionTx(ctx, tx, version, dirty); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setMigrationVersionTx replaces the stored schema version, clean version 0 is stored as no rows
+func setMigrationVersionTx(ctx context.Context, tx *sqlx.Tx, version uint, dirty bool) error {
+	if _, err := tx.ExecContext(ctx, `TRUNCATE schema_migrations;`); err != nil {
+		return err
+	}
+
+	if version == 0 && !dirty {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2);`, version, dirty)
+	return err
+}
@@ -5,14 +5,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/l-golofastov/subscriptions-manager/internal/config"
 	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/l-golofastov/subscriptions-manager/internal/metrics"
 	"github.com/l-golofastov/subscriptions-manager/internal/repository"
-	_ "github.com/lib/pq"
+	"github.com/l-golofastov/subscriptions-manager/internal/reqctx"
+	"github.com/l-golofastov/subscriptions-manager/internal/tracing"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type StoragePostgres struct {
@@ -23,8 +30,8 @@ func NewStoragePostgres(cfg *config.Config) (*StoragePostgres, error) {
 	const op = "repository.postgres.NewStoragePostgres"
 
 	connectionString := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.DB,
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable&application_name=%s",
+		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.DB, tracing.ServiceName,
 	)
 
 	db, err := sqlx.Connect("postgres", connectionString)
@@ -35,6 +42,11 @@ func NewStoragePostgres(cfg *config.Config) (*StoragePostgres, error) {
 	db.SetMaxOpenConns(20)
 	db.SetMaxIdleConns(5)
 
+	if err := metrics.RegisterDBStats(db.DB, cfg.Postgres.DB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return &StoragePostgres{db: db}, nil
 }
 
@@ -42,37 +54,164 @@ func (s *StoragePostgres) Close() error {
 	return s.db.Close()
 }
 
-func (s *StoragePostgres) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+func (s *StoragePostgres) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Stats returns database connection pool statistics
+func (s *StoragePostgres) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// sortColumns maps list sort fields to columns and casts applied to cursor values
+var sortColumns = map[string]struct {
+	column string
+	cast   string
+}{
+	domain.SortByCreatedAt:   {column: "created_at", cast: "timestamp"},
+	domain.SortByStartDate:   {column: "start_date", cast: "date"},
+	domain.SortByPrice:       {column: "price", cast: "integer"},
+	domain.SortByServiceName: {column: "service_name", cast: "text"},
+}
+
+func (s *StoragePostgres) ListSubscriptions(ctx context.Context, in domain.ListSubscriptionsFilter) (_ *domain.SubscriptionsPage, err error) {
 	const op = "repository.postgres.ListSubscriptions"
 
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
 	subscriptions := make([]domain.Subscription, 0)
 
+	sort, ok := sortColumns[in.Sort]
+	if !ok {
+		sort = sortColumns[domain.SortByCreatedAt]
+		in.Sort = domain.SortByCreatedAt
+	}
+
+	direction, comparison := "DESC", "<"
+	if in.Order == domain.OrderAsc {
+		direction, comparison = "ASC", ">"
+	} else {
+		in.Order = domain.OrderDesc
+	}
+
+	limit := in.Limit
+	if limit <= 0 || limit > domain.MaxListLimit {
+		limit = domain.DefaultListLimit
+	}
+
+	var conditions queryConditions
+
+	if in.UserID != nil {
+		conditions.add("user_id = %s", *in.UserID)
+	}
+
+	if in.ServiceName != nil {
+		conditions.add("service_name = %s", *in.ServiceName)
+	}
+
+	if in.ServiceNamePrefix != nil {
+		conditions.add(`service_name LIKE %s ESCAPE '\'`, escapeLike(*in.ServiceNamePrefix)+"%")
+	}
+
+	if in.MinPrice != nil {
+		conditions.add("price >= %s", *in.MinPrice)
+	}
+
+	if in.MaxPrice != nil {
+		conditions.add("price <= %s", *in.MaxPrice)
+	}
+
+	if in.ActiveIn != nil {
+		activeIn := in.ActiveIn.MonthYearPtrToTimePtr()
+		conditions.add("start_date <= %[1]s::date AND (end_date IS NULL OR end_date >= %[1]s::date)", activeIn)
+	}
+
+	if !in.IncludeDeleted {
+		conditions.add("deleted_at IS NULL")
+	}
+
+	if in.Cursor != nil {
+		conditions.add(
+			fmt.Sprintf("(%s, id) %s (%%s::%s, %%s)", sort.column, comparison, sort.cast),
+			in.Cursor.Value, in.Cursor.ID,
+		)
+	}
+
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		SELECT id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at
 		FROM subscriptions
-		ORDER BY created_at DESC;
 	`
 
-	err := s.db.SelectContext(ctx, &subscriptions, query)
+	query += conditions.where()
+	query += fmt.Sprintf("ORDER BY %[1]s %[2]s, id %[2]s LIMIT %[3]s;", sort.column, direction, conditions.arg(limit+1))
+
+	err = s.db.SelectContext(ctx, &subscriptions, tagQuery(ctx, query), conditions.args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return subscriptions, nil
+	page := domain.SubscriptionsPage{Items: subscriptions}
+
+	if len(subscriptions) > limit {
+		page.Items = subscriptions[:limit]
+		page.NextCursor = domain.NewListCursor(&page.Items[limit-1], in.Sort, in.Order).Encode()
+	}
+
+	return &page, nil
+}
+
+// observe records duration and result of the storage operation and ends its span
+func observe(span trace.Span, op string, start time.Time, err *error) {
+	failed := *err != nil && !errors.Is(*err, repository.ErrNotFound)
+	metrics.ObserveStorageOperation(op, time.Since(start), failed)
+
+	if failed {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// startSpan starts span of the storage operation named by op as a child of the span in ctx
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+	)
 }
 
-func (s *StoragePostgres) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+// tagQuery prefixes query with a comment holding ID of the request in ctx,
+// so that statements in PostgreSQL logs can be matched to API log lines
+func tagQuery(ctx context.Context, query string) string {
+	requestID := reqctx.GetRequestID(ctx)
+	if requestID == "" || strings.Contains(requestID, "*/") {
+		return query
+	}
+
+	return "/* request_id=" + requestID + " */ " + strings.TrimLeft(query, " \t\n")
+}
+
+// escapeLike escapes LIKE pattern special characters
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (s *StoragePostgres) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (_ *domain.Subscription, err error) {
 	const op = "repository.postgres.GetSubscriptionByID"
 
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
 	var subscription domain.Subscription
 
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		SELECT id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at
 		FROM subscriptions
-		WHERE id = $1;
+		WHERE id = $1 AND deleted_at IS NULL;
 	`
 
-	err := s.db.GetContext(ctx, &subscription, query, id)
+	err = s.db.GetContext(ctx, &subscription, tagQuery(ctx, query), id)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, repository.ErrNotFound
@@ -85,120 +224,540 @@ func (s *StoragePostgres) GetSubscriptionByID(ctx context.Context, id uuid.UUID)
 	return &subscription, nil
 }
 
-func (s *StoragePostgres) CreateSubscription(ctx context.Context, in domain.CreateSubscriptionInput) (*domain.Subscription, error) {
+// GetSubscriptionOwner returns user ID of the subscription including soft deleted one
+func (s *StoragePostgres) GetSubscriptionOwner(ctx context.Context, id uuid.UUID) (_ uuid.UUID, err error) {
+	const op = "repository.postgres.GetSubscriptionOwner"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	var userID uuid.UUID
+
+	query := `
+		SELECT user_id
+		FROM subscriptions
+		WHERE id = $1;
+	`
+
+	err = s.db.GetContext(ctx, &userID, tagQuery(ctx, query), id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (s *StoragePostgres) CreateSubscription(ctx context.Context, in domain.CreateSubscriptionInput) (_ *domain.Subscription, err error) {
 	const op = "repository.postgres.CreateSubscription"
 
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	sub, err := createSubscription(ctx, tx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sub, nil
+}
+
+// createSubscription inserts the subscription with its price and records history
+func createSubscription(ctx context.Context, tx *sqlx.Tx, in domain.CreateSubscriptionInput) (*domain.Subscription, error) {
 	var subscription domain.Subscription
 
 	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at;
+		INSERT INTO subscriptions (service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at;
 	`
 
 	startDate := in.StartDate.MonthYearPtrToTimePtr()
 	endDate := in.EndDate.MonthYearPtrToTimePtr()
 
-	err := s.db.QueryRowxContext(ctx, query, in.ServiceName, in.Price, in.UserID, startDate, endDate).StructScan(&subscription)
+	err := tx.QueryRowxContext(ctx, tagQuery(ctx, query), in.ServiceName, in.Price, in.Currency, in.BillingPeriod, in.BillingInterval, in.UserID, startDate, endDate).StructScan(&subscription)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
+	}
+
+	err = setPrice(ctx, tx, subscription.ID, subscription.Price, subscription.StartDate, subscription.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertHistory(ctx, tx, domain.HistoryActionCreate, subscription.ID, nil, &subscription)
+	if err != nil {
+		return nil, err
 	}
 
 	return &subscription, nil
 }
 
-func (s *StoragePostgres) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+func (s *StoragePostgres) DeleteSubscription(ctx context.Context, id uuid.UUID) (err error) {
 	const op = "repository.postgres.DeleteSubscription"
 
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	err = deleteSubscription(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// deleteSubscription soft deletes the subscription and records history
+func deleteSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
 	query := `
-		DELETE FROM subscriptions
+		UPDATE subscriptions
+		SET deleted_at = now(), version = version + 1
 		WHERE id = $1;
 	`
 
-	result, err := s.db.ExecContext(ctx, query, id)
+	_, err := changeSubscription(ctx, tx, id, domain.HistoryActionDelete, query, false)
+
+	return err
+}
+
+func (s *StoragePostgres) RestoreSubscription(ctx context.Context, id uuid.UUID) (_ *domain.Subscription, err error) {
+	const op = "repository.postgres.RestoreSubscription"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = NULL, version = version + 1, updated_at = now()
+		WHERE id = $1;
+	`
+
+	subscription, err := changeSubscription(ctx, tx, id, domain.HistoryActionRestore, query, true)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subscription, nil
+}
+
+// changeSubscription locks the subscription row in the given deleted state, executes the query
+// changing it and records history of the change
+func changeSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, action, query string, deleted bool) (*domain.Subscription, error) {
+	var old domain.Subscription
+
+	lockQuery := `
+		SELECT id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at
+		FROM subscriptions
+		WHERE id = $1 AND (deleted_at IS NOT NULL) = $2
+		FOR UPDATE;
+	`
+
+	err := tx.GetContext(ctx, &old, tagQuery(ctx, lockQuery), id, deleted)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, tagQuery(ctx, query), id)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription domain.Subscription
+
+	selectQuery := `
+		SELECT id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at
+		FROM subscriptions
+		WHERE id = $1;
+	`
+
+	err = tx.GetContext(ctx, &subscription, tagQuery(ctx, selectQuery), id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertHistory(ctx, tx, action, id, &old, &subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// PurgeSubscription hard deletes subscription including soft deleted one, its history is kept
+// and the purge is recorded in it within the same transaction
+func (s *StoragePostgres) PurgeSubscription(ctx context.Context, id uuid.UUID) (err error) {
+	const op = "repository.postgres.PurgeSubscription"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback()
 
-	if rowsAffected == 0 {
+	var old domain.Subscription
+
+	query := `
+		DELETE FROM subscriptions
+		WHERE id = $1
+		RETURNING id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at;
+	`
+
+	err = tx.GetContext(ctx, &old, tagQuery(ctx, query), id)
+	if errors.Is(err, sql.ErrNoRows) {
 		return repository.ErrNotFound
 	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = insertHistory(ctx, tx, domain.HistoryActionPurge, id, &old, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	return nil
 }
 
-func (s *StoragePostgres) UpdateSubscription(ctx context.Context, id uuid.UUID, in domain.UpdateSubscriptionInput) (*domain.Subscription, error) {
+// PurgeDeletedSubscriptions hard deletes subscriptions soft deleted more than retention ago
+// and records purge of each of them in history within the same transaction
+func (s *StoragePostgres) PurgeDeletedSubscriptions(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	const op = "repository.postgres.PurgeDeletedSubscriptions"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	purged := make([]domain.Subscription, 0)
+
+	query := `
+		DELETE FROM subscriptions
+		WHERE deleted_at IS NOT NULL
+		  AND deleted_at < now() - make_interval(secs => $1)
+		RETURNING id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at;
+	`
+
+	err = tx.SelectContext(ctx, &purged, tagQuery(ctx, query), retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range purged {
+		err = insertHistory(ctx, tx, domain.HistoryActionPurge, purged[i].ID, &purged[i], nil)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int64(len(purged)), nil
+}
+
+func (s *StoragePostgres) UpdateSubscription(ctx context.Context, id uuid.UUID, in domain.UpdateSubscriptionInput) (_ *domain.Subscription, err error) {
 	const op = "repository.postgres.UpdateSubscription"
 
-	sub, err := s.GetSubscriptionByID(ctx, id)
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback()
 
-	if in.ServiceName != nil {
-		sub.ServiceName = *in.ServiceName
+	sub, err := updateSubscription(ctx, tx, id, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if in.Price != nil {
-		sub.Price = *in.Price
+	return sub, nil
+}
+
+// updateSubscription locks the subscription row, checks expected version, writes merged fields and records history
+func updateSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, in domain.UpdateSubscriptionInput) (*domain.Subscription, error) {
+	var sub domain.Subscription
+
+	query := `
+		SELECT id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at
+		FROM subscriptions
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE;
+	`
+
+	err := tx.GetContext(ctx, &sub, tagQuery(ctx, query), id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
 	}
 
-	if in.StartDate != nil {
-		sub.StartDate = *in.StartDate
+	if err != nil {
+		return nil, err
 	}
 
-	if in.EndDate != nil {
-		sub.EndDate = *in.EndDate
+	if in.ExpectedVersion != nil && *in.ExpectedVersion != sub.Version {
+		return nil, repository.ErrVersionMismatch
 	}
 
-	sub.UpdatedAt = time.Now()
+	old := sub
+
+	in.Apply(&sub)
+
+	if errs := sub.ValidatePeriod(); errs != nil {
+		return nil, errs
+	}
 
 	var updatedSubscription domain.Subscription
 
-	query := `
+	query = `
 		UPDATE subscriptions
-		SET service_name = $1, price = $2, start_date = $3, end_date = $4, updated_at = $5
-		WHERE id = $6
-		RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at;
+		SET service_name = $1, price = $2, currency = $3, billing_period = $4, billing_interval = $5,
+		    start_date = $6, end_date = $7, version = version + 1, updated_at = now()
+		WHERE id = $8
+		RETURNING id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, version, created_at, updated_at, deleted_at;
 	`
 
 	startDate := sub.StartDate.MonthYearPtrToTimePtr()
 	endDate := sub.EndDate.MonthYearPtrToTimePtr()
 
-	err = s.db.QueryRowxContext(ctx, query, sub.ServiceName, sub.Price, startDate, endDate, sub.UpdatedAt, id).StructScan(&updatedSubscription)
+	err = tx.QueryRowxContext(
+		ctx, tagQuery(ctx, query), sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingInterval, startDate, endDate, id,
+	).StructScan(&updatedSubscription)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
+	}
+
+	if in.ChangesPrice(&old) {
+		from := updatedSubscription.StartDate
+		if in.PriceEffectiveFrom != nil {
+			from = *in.PriceEffectiveFrom
+		}
+
+		err = setPrice(ctx, tx, id, *in.Price, from, updatedSubscription.StartDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = insertHistory(ctx, tx, domain.HistoryActionUpdate, id, &old, &updatedSubscription)
+	if err != nil {
+		return nil, err
 	}
 
 	return &updatedSubscription, nil
 }
 
-func (s *StoragePostgres) SumSubscriptionsPrices(ctx context.Context, in domain.SumSubscriptionsFilter) (int, error) {
+func (s *StoragePostgres) SumSubscriptionsPrices(ctx context.Context, in domain.SumSubscriptionsFilter) (_ *domain.SubscriptionsSum, err error) {
 	const op = "repository.postgres.SumSubscriptionsPrices"
 
-	var total sql.NullInt64
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	costs := make([]domain.SubscriptionCost, 0)
+
+	in.SetDefaults()
+
+	if errs := in.Validate(); errs != nil {
+		return nil, fmt.Errorf("%s: %w", op, errs)
+	}
+
+	var conditions queryConditions
+
+	from := conditions.arg(in.From.MonthYearPtrToTimePtr())
+	to := conditions.arg(in.To.MonthYearPtrToTimePtr())
+
+	conditions.add("deleted_at IS NULL")
+	conditions.add(fmt.Sprintf("start_date <= %s::date", to))
+
+	if in.From != nil {
+		conditions.add(fmt.Sprintf("(end_date IS NULL OR end_date >= %s::date)", from))
+	}
+
+	if len(in.UserIDs) > 0 {
+		conditions.add("user_id = ANY(%s)", pq.Array(in.UserIDs))
+	}
+
+	if len(in.ServiceNames) > 0 {
+		conditions.add("service_name = ANY(%s)", pq.Array(in.ServiceNames))
+	}
+
+	// subscription period is clamped to [from, to] and counted in whole months, both ends inclusive,
+	// subscriptions are billed from their start if from is not set,
+	// prices are normalised to months by billing period and converted to the currency afterwards
+	query := fmt.Sprintf(`
+		SELECT id, service_name, price, currency, billing_period, billing_interval, billed_from, billed_to,
+		       ((EXTRACT(YEAR FROM billed_to) - EXTRACT(YEAR FROM billed_from)) * 12
+		        + EXTRACT(MONTH FROM billed_to) - EXTRACT(MONTH FROM billed_from) + 1)::int AS months
+		FROM (
+			SELECT id, service_name, price, currency, billing_period, billing_interval, start_date,
+			       GREATEST(start_date, %[1]s::date) AS billed_from,
+			       LEAST(COALESCE(end_date, %[2]s::date), %[2]s::date) AS billed_to
+			FROM subscriptions
+			%[3]s
+		) AS billed
+		ORDER BY start_date, id;
+	`, from, to, conditions.where())
+
+	err = s.db.SelectContext(ctx, &costs, tagQuery(ctx, query), conditions.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(costs))
+	for _, cost := range costs {
+		ids = append(ids, cost.SubscriptionID)
+	}
+
+	schedules, err := s.listPriceSchedules(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range costs {
+		costs[i].Prices = schedules[costs[i].SubscriptionID]
+	}
+
+	currency := in.Currency
+
+	rates := domain.NewExchangeRates(nil)
+
+	if domain.NeedsConversion(costs, currency) {
+		loaded, err := s.ListExchangeRates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rates = domain.NewExchangeRates(loaded)
+	}
+
+	sum, err := domain.SumCosts(costs, currency, rates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sum, nil
+}
+
+func (s *StoragePostgres) ListExchangeRates(ctx context.Context) (_ []domain.ExchangeRate, err error) {
+	const op = "repository.postgres.ListExchangeRates"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	rates := make([]domain.ExchangeRate, 0)
 
 	query := `
-		SELECT COALESCE(SUM(price), 0)
-		FROM subscriptions
-		WHERE user_id = $1
-		  AND service_name = $2
-		  AND start_date <= $4
-		  AND (end_date IS NULL OR end_date >= $3);
+		SELECT currency, month, rate
+		FROM exchange_rates
+		ORDER BY currency, month;
+	`
+
+	err = s.db.SelectContext(ctx, &rates, tagQuery(ctx, query))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rates, nil
+}
+
+func (s *StoragePostgres) UpsertExchangeRates(ctx context.Context, rates []domain.ExchangeRate) (err error) {
+	const op = "repository.postgres.UpsertExchangeRates"
+
+	ctx, span := startSpan(ctx, op)
+	defer observe(span, op, time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO exchange_rates (currency, month, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (currency, month) DO UPDATE SET rate = EXCLUDED.rate;
 	`
 
-	from := in.From.MonthYearPtrToTimePtr()
-	to := in.To.MonthYearPtrToTimePtr()
+	for _, rate := range rates {
+		month := rate.Month.MonthYearPtrToTimePtr()
 
-	err := s.db.GetContext(ctx, &total, query, in.UserID, in.ServiceName, from, to)
+		_, err = tx.ExecContext(ctx, tagQuery(ctx, query), rate.Currency, month, rate.Rate)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return int(total.Int64), nil
+	return nil
 }
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/l-golofastov/subscriptions-manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+// listPriceSchedules returns price schedules of the subscriptions by subscription ID
+func (s *StoragePostgres) listPriceSchedules(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]domain.PriceSchedule, error) {
+	rows := make([]struct {
+		SubscriptionID uuid.UUID `db:"subscription_id"`
+		domain.PriceSegment
+	}, 0)
+
+	query := `
+		SELECT subscription_id, effective_from, price
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, effective_from;
+	`
+
+	err := s.db.SelectContext(ctx, &rows, tagQuery(ctx, query), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[uuid.UUID]domain.PriceSchedule)
+	for _, row := range rows {
+		schedules[row.SubscriptionID] = append(schedules[row.SubscriptionID], row.PriceSegment)
+	}
+
+	return schedules, nil
+}
+
+// setPrice writes the price effective from the month replacing segments starting later.
+// Price effective from the subscription start or earlier replaces the whole schedule.
+func setPrice(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, price int, from, start domain.MonthYear) error {
+	query := `
+		DELETE FROM subscription_prices
+		WHERE subscription_id = $1 AND effective_from >= $2;
+	`
+	args := []any{id, from.Time()}
+
+	if !from.Time().After(start.Time()) {
+		from = start
+
+		query = `
+			DELETE FROM subscription_prices
+			WHERE subscription_id = $1;
+		`
+		args = []any{id}
+	}
+
+	_, err := tx.ExecContext(ctx, tagQuery(ctx, query), args...)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price)
+		VALUES ($1, $2, $3);
+	`
+
+	_, err = tx.ExecContext(ctx, tagQuery(ctx, query), id, from.Time(), price)
+
+	return err
+}
@@ -0,0 +1,43 @@
+// Package reqctx stores request scoped values in context,
+// so that both HTTP server and storage layers can read them.
+package reqctx
+
+import "context"
+
+type requestIDKeyType struct{}
+
+var requestIDKey requestIDKeyType
+
+type actorKeyType struct{}
+
+var actorKey actorKeyType
+
+// WithRequestID stores ID of the request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func GetRequestID(ctx context.Context) string {
+	if v := ctx.Value(requestIDKey); v != nil {
+		if id, ok := v.(string); ok {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// WithActor stores identity of the client performing the request
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func GetActor(ctx context.Context) string {
+	if v := ctx.Value(actorKey); v != nil {
+		if actor, ok := v.(string); ok {
+			return actor
+		}
+	}
+
+	return ""
+}